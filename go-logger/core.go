@@ -15,6 +15,7 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/pokeyaro/gopkg/go-logger/utils"
+	"github.com/pokeyaro/gopkg/suprelog/callstack"
 )
 
 // logCore represents the core parameters.
@@ -37,9 +38,16 @@ func (lc *logCore) setLogger(l Level, dt int, isColorful bool, recordToFile Reco
 
 	// Only records to file if the log level is equal to or higher than the trigger level
 	if isRecordFile && l >= triggerLevel {
-		file, err := os.OpenFile(filePath+logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-		if err != nil {
-			panic(err.Error())
+		var file io.Writer
+		if rw, ok := recordToFile.(RecordWriter); ok && rw.GetWriter() != nil {
+			// Use the writer supplied by the rule, e.g. a rotating file
+			file = rw.GetWriter()
+		} else {
+			f, err := os.OpenFile(filePath+logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+			if err != nil {
+				panic(err.Error())
+			}
+			file = f
 		}
 
 		if l >= LevelError {
@@ -83,19 +91,26 @@ func (lc *logCore) logf(entry *Entry, logLevel Level, format *string, args ...an
 	lc.setLogger(logLevel, timeFormat, entry.isColorful, entry.recordToFile)
 
 	funcPos := lc.getFuncPos(entry.trackAbsPath)
+	var content string
 	if format == nil {
 		contentText := "%+v"
 		if entry.isColorful {
 			contentText = lc.logRefColor(logLevel, "%+v", true, false)
 		}
-		lc.logger.Printf(funcPos+contentText, fmt.Sprint(args...))
+		content = fmt.Sprintf(funcPos+contentText, fmt.Sprint(args...))
 	} else {
 		contentText := *format
 		if entry.isColorful {
 			contentText = lc.logRefColor(logLevel, *format, true, false)
 		}
-		lc.logger.Printf(funcPos+contentText, args...)
+		content = fmt.Sprintf(funcPos+contentText, args...)
+	}
+
+	// Append the call stack as an indented block below the log
+	if entry.stackTrace && logLevel >= entry.stackLevel {
+		content += lc.getStack(entry.trackAbsPath)
 	}
+	lc.logger.Print(content)
 
 	if logLevel == LevelFatal {
 		os.Exit(1)
@@ -113,3 +128,23 @@ func (lc *logCore) getFuncPos(isAbsPath bool) string {
 	}
 	return utils.Sprintf("%s:%s - ", file, strconv.Itoa(lineno))
 }
+
+// getStack retrieves the call stack from the log position outwards, one function
+// per line followed by its file:line, indented by tabs.
+func (lc *logCore) getStack(isAbsPath bool) string {
+	var b strings.Builder
+	for _, frame := range callstack.Callers(3) {
+		file, ok := callstack.ShortFile(frame.File)
+		if !ok && !isAbsPath {
+			path := strings.Split(file, "/")
+			if len(path) > 2 {
+				file = strings.Join(path[len(path)-2:], "/")
+			}
+		}
+		b.WriteString("\n\t")
+		b.WriteString(frame.Function[strings.LastIndex(frame.Function, "/")+1:])
+		b.WriteString("\n\t\t")
+		b.WriteString(utils.Sprintf("%s:%s", file, strconv.Itoa(frame.Line)))
+	}
+	return b.String()
+}
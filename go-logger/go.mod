@@ -1,11 +1,17 @@
 module github.com/pokeyaro/gopkg/go-logger
 
-go 1.20
+go 1.21
 
-require github.com/fatih/color v1.15.0
+require (
+	github.com/fatih/color v1.15.0
+	github.com/pokeyaro/gopkg/suprelog v0.0.0
+)
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.17 // indirect
 	golang.org/x/sys v0.6.0 // indirect
 )
+
+// The modules of this repository are developed together
+replace github.com/pokeyaro/gopkg/suprelog => ../suprelog
@@ -55,6 +55,10 @@ type Entry struct {
 	// The rule for logging records to a file
 	recordToFile RecordRule
 
+	// Indicates whether to append the call stack to the logs at or above the stack level
+	stackTrace bool
+	stackLevel Level
+
 	// The underlying log core instance
 	lc *logCore
 }
@@ -69,6 +73,7 @@ func New() *Entry {
 		recordToFile: &FileRecord{
 			ShouldRec: false,
 		},
+		stackLevel: LevelError,
 		lc: &logCore{
 			w: io.Discard,
 		},
@@ -6,6 +6,8 @@
 package logger
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -24,3 +26,46 @@ func TestSetupDev(t *testing.T) {
 func TestSetupProd(t *testing.T) {
 	_ = SetupProd()
 }
+
+func TestFileRecord_Writer(t *testing.T) {
+	var buf bytes.Buffer
+
+	log := New()
+	log.SetLevel(LevelDebug).SetRecordToFile(&FileRecord{
+		ShouldRec: true,
+		Trigger:   LevelWarn,
+		Writer:    &buf,
+	})
+
+	log.Info("not recorded")
+	log.Warnf("recorded %d", 1)
+
+	if got := buf.String(); strings.Contains(got, "not recorded") || !strings.Contains(got, "recorded 1") {
+		t.Errorf("unexpected record output %q", got)
+	}
+}
+
+func TestEntry_StackTrace(t *testing.T) {
+	var buf bytes.Buffer
+
+	log := New()
+	log.SetLevel(LevelDebug).SetStackTrace(true).SetRecordToFile(&FileRecord{
+		ShouldRec: true,
+		Trigger:   LevelWarn,
+		Writer:    &buf,
+	})
+
+	log.Warn("no stack")
+	log.Errorf("failed %d", 1)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) < 4 || strings.HasPrefix(lines[1], "\t") {
+		t.Fatalf("unexpected record output %q", buf.String())
+	}
+	if lines[2] != "\tgo-logger.TestEntry_StackTrace" || !strings.HasPrefix(lines[3], "\t\tgo-logger/logger_test.go:") {
+		t.Errorf("stack does not start at the log position %q", buf.String())
+	}
+	if strings.Contains(buf.String(), "logCore") {
+		t.Errorf("stack contains the frames of the logger %q", buf.String())
+	}
+}
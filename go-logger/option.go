@@ -5,10 +5,6 @@
 // Package logger provides a simple, lightweight logging library for Go.
 package logger
 
-import (
-	"github.com/pokeyaro/gopkg/go-logger/utils"
-)
-
 type EntryFunc func(*Entry)
 type EntryChain []EntryFunc
 
@@ -50,9 +46,21 @@ func WithEnableColors(isEnabled bool) EntryFunc {
 func WithRecordToFile(record RecordRule) EntryFunc {
 	return func(entry *Entry) {
 		filePath := record.GetPosition()
-		if err := utils.Mkdir(filePath); err != nil {
+		if err := mkdirRecord(record, filePath); err != nil {
 			panic(err.Error())
 		}
 		entry.recordToFile = record
 	}
 }
+
+func WithStackTrace(isEnabled bool) EntryFunc {
+	return func(entry *Entry) {
+		entry.stackTrace = isEnabled
+	}
+}
+
+func WithStackLevel(l Level) EntryFunc {
+	return func(entry *Entry) {
+		entry.stackLevel = l
+	}
+}
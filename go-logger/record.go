@@ -5,6 +5,12 @@
 // Package logger provides a simple, lightweight logging library for Go.
 package logger
 
+import (
+	"io"
+
+	"github.com/pokeyaro/gopkg/go-logger/utils"
+)
+
 // RecordRule defines an interface for configuring logging rules.
 type RecordRule interface {
 	ShouldRecord() bool  // Indicates whether to store the log record or not.
@@ -12,11 +18,18 @@ type RecordRule interface {
 	GetTrigger() Level   // Returns the triggering level for logging.
 }
 
+// RecordWriter is an optional interface for a RecordRule that supplies its
+// own writer, such as a rotating file writer, instead of records.log.
+type RecordWriter interface {
+	GetWriter() io.Writer // Returns the writer for the log records, or nil for the default file.
+}
+
 // FileRecord represents the configuration for file logging.
 type FileRecord struct {
-	ShouldRec bool   // Indicates whether file logging is enabled or not
-	FilePath  string // The file path where logs will be stored
-	Trigger   Level  // The trigger level for file logging
+	ShouldRec bool      // Indicates whether file logging is enabled or not
+	FilePath  string    // The file path where logs will be stored
+	Trigger   Level     // The trigger level for file logging
+	Writer    io.Writer // Optional writer used instead of FilePath, e.g. a suprelog.RotateWriter
 }
 
 // ShouldRecord returns whether file logging is enabled or not.
@@ -33,3 +46,16 @@ func (fr *FileRecord) GetPosition() string {
 func (fr *FileRecord) GetTrigger() Level {
 	return fr.Trigger
 }
+
+// GetWriter returns the writer used instead of FilePath, if any.
+func (fr *FileRecord) GetWriter() io.Writer {
+	return fr.Writer
+}
+
+// mkdirRecord creates the log directory of the rule, unless the rule supplies its own writer.
+func mkdirRecord(record RecordRule, filePath string) error {
+	if rw, ok := record.(RecordWriter); ok && rw.GetWriter() != nil {
+		return nil
+	}
+	return utils.Mkdir(filePath)
+}
@@ -5,10 +5,6 @@
 // Package logger provides a simple, lightweight logging library for Go.
 package logger
 
-import (
-	"github.com/pokeyaro/gopkg/go-logger/utils"
-)
-
 func (entry *Entry) SetLevel(l Level) *Entry {
 	entry.level = l
 	return entry
@@ -29,10 +25,20 @@ func (entry *Entry) SetEnableColors(enable bool) *Entry {
 	return entry
 }
 
+func (entry *Entry) SetStackTrace(enable bool) *Entry {
+	entry.stackTrace = enable
+	return entry
+}
+
+func (entry *Entry) SetStackLevel(l Level) *Entry {
+	entry.stackLevel = l
+	return entry
+}
+
 func (entry *Entry) SetRecordToFile(record RecordRule) *Entry {
 	filePath := record.GetPosition()
 
-	if err := utils.Mkdir(filePath); err != nil {
+	if err := mkdirRecord(record, filePath); err != nil {
 		panic(err.Error())
 	}
 
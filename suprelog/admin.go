@@ -0,0 +1,134 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/goccy/go-json"
+)
+
+// LevelHandler returns an http.Handler for changing the log level of h at
+// runtime, e.g. to turn on DEBUG in production for a while:
+//
+//	GET       reports the current level and the effective configuration
+//	PUT, POST sets the level given as {"level":"DEBUG"}, as a form or query
+//	          value level=DEBUG, and reports the new state
+//
+// Levels are given by name, case-insensitively, or by integer value. The
+// change applies to all handlers derived from h.
+func (h *Handler) LevelHandler() http.Handler {
+	return &levelServer{h: h}
+}
+
+type levelServer struct {
+	h *Handler
+}
+
+// levelState is the body of the responses of the level endpoint.
+type levelState struct {
+	Level  string      `json:"level"`
+	Config levelConfig `json:"config"`
+}
+
+// levelConfig is the effective configuration of a Handler.
+type levelConfig struct {
+	TimeFormat  string   `json:"time_format"`
+	BuiltinSort []string `json:"builtin_sort"`
+	LogMode     string   `json:"log_mode"`
+	TypMode     string   `json:"typ_mode"`
+	Colorful    bool     `json:"colorful"`
+	AbsPath     bool     `json:"abs_path"`
+	FuncName    bool     `json:"func_name"`
+	CallerSkip  int      `json:"caller_skip"`
+	ExitCode    int      `json:"exit_code"`
+	Writer      string   `json:"writer"`
+}
+
+func (s *levelServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		name, err := requestedLevel(r)
+		if err != nil {
+			writeLevelError(w, http.StatusBadRequest, err)
+			return
+		}
+		l, err := ParseLevel(name)
+		if err != nil {
+			writeLevelError(w, http.StatusBadRequest, err)
+			return
+		}
+		s.h.level.Set(l)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		writeLevelError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.state())
+}
+
+// state returns the current level and configuration of the handler.
+func (s *levelServer) state() levelState {
+	h := s.h
+	logMode := "simplify"
+	if h.mode.log == ModeDetail {
+		logMode = "detail"
+	}
+	return levelState{
+		Level: h.level.Get().String(),
+		Config: levelConfig{
+			TimeFormat:  h.timeFmt,
+			BuiltinSort: h.builtinSort,
+			LogMode:     logMode,
+			TypMode:     h.mode.typ,
+			Colorful:    h.isColorful,
+			AbsPath:     h.absPath,
+			FuncName:    h.funcName,
+			CallerSkip:  h.callerSkip,
+			ExitCode:    h.exitCode,
+			Writer:      fmt.Sprintf("%T", h.w),
+		},
+	}
+}
+
+// requestedLevel returns the level name sent with a PUT or POST request.
+func requestedLevel(r *http.Request) (string, error) {
+	if level := r.URL.Query().Get("level"); level != "" {
+		return level, nil
+	}
+
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if ct == "application/x-www-form-urlencoded" {
+		if level := r.PostFormValue("level"); level != "" {
+			return level, nil
+		}
+		return "", errors.New("missing level")
+	}
+
+	var body struct {
+		Level string `json:"level"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<10)).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid body: %w", err)
+	}
+	if body.Level == "" {
+		return "", errors.New("missing level")
+	}
+	return body.Level, nil
+}
+
+func writeLevelError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHandler_LevelHandler(t *testing.T) {
+	h := HandlerOptions(WithWriter(io.Discard), WithLogLevel(LevelInfo))
+	child := h.WithAttrs([]slog.Attr{slog.String("k", "v")})
+	srv := httptest.NewServer(h.LevelHandler())
+	defer srv.Close()
+
+	tests := []struct {
+		method, ctype, body string
+		code                int
+		level               string
+	}{
+		{http.MethodGet, "", "", http.StatusOK, `"level":"INFO"`},
+		{http.MethodPut, "application/json", `{"level":"debug"}`, http.StatusOK, `"level":"DEBUG"`},
+		{http.MethodPost, "application/x-www-form-urlencoded", url.Values{"level": {"TRACE"}}.Encode(), http.StatusOK, `"level":"TRACE"`},
+		{http.MethodPut, "application/json", `{"level":"verbose"}`, http.StatusBadRequest, `unknown level`},
+		{http.MethodDelete, "", "", http.StatusMethodNotAllowed, `not allowed`},
+		{http.MethodGet, "", "", http.StatusOK, `"level":"TRACE","config":{"time_format":"2006-01-02"`},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, srv.URL, strings.NewReader(tt.body))
+		if tt.ctype != "" {
+			req.Header.Set("Content-Type", tt.ctype)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.code || !bytes.Contains(body, []byte(tt.level)) {
+			t.Errorf("%s %s: got %d %s, want %d containing %s", tt.method, tt.body, resp.StatusCode, body, tt.code, tt.level)
+		}
+	}
+
+	if !child.Enabled(context.Background(), LevelTrace.Level()) {
+		t.Error("level change did not apply to derived handler")
+	}
+}
+
+func TestLevelVar_Concurrent(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(WithWriter(&buf), WithLogLevel(LevelInfo))
+	log := slog.New(h)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			h.SetLogLevel(LevelDebug)
+			h.LevelVar().Set(LevelWarn)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			log.Debug("msg")
+		}
+	}()
+	wg.Wait()
+
+	if got := h.LevelVar().String(); got != "LevelVar(WARN)" {
+		t.Errorf("got %s", got)
+	}
+}
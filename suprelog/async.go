@@ -0,0 +1,286 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy decides what an AsyncHandler does with a record
+// when its queue is full.
+type OverflowPolicy int
+
+// Overflow policies.
+const (
+	OverflowBlock      OverflowPolicy = iota // wait for room in the queue
+	OverflowDropNewest                       // drop the incoming record
+	OverflowDropOldest                       // drop the oldest queued record
+	OverflowKeepErrors                       // drop the incoming record below ERROR, wait for ERROR and FATAL
+)
+
+// AsyncOptions configures an AsyncHandler.
+type AsyncOptions struct {
+	QueueSize     int            // Capacity of the record queue, 1024 if not positive
+	Overflow      OverflowPolicy // Policy applied when the queue is full
+	FlushInterval time.Duration  // Period of automatic flushes, 0 disables them
+	OnError       func(error)    // Optional callback for errors of the wrapped handler
+}
+
+// AsyncHandler is a slog.Handler that queues records and hands them to
+// the wrapped handler on a background goroutine, so that slow writers do
+// not stall the logging goroutines. FATAL records are handled synchronously
+// after the queue has been flushed.
+type AsyncHandler struct {
+	handler slog.Handler
+	core    *asyncCore
+}
+
+// asyncCore is the queue and worker shared by an AsyncHandler and the
+// handlers derived from it with WithAttrs and WithGroup.
+type asyncCore struct {
+	opts    AsyncOptions
+	queue   chan asyncItem
+	mu      sync.RWMutex // guards closed and sends on queue
+	closed  bool
+	dropMu  sync.Mutex // serializes drop-oldest enqueues
+	dropped atomic.Uint64
+	done    chan struct{}
+	flusher func() error // flushes the wrapped handler, if it buffers output
+}
+
+// asyncItem is a queued record, or a flush request if flushed is not nil.
+type asyncItem struct {
+	h       slog.Handler
+	ctx     context.Context
+	r       slog.Record
+	flushed chan struct{}
+}
+
+// NewAsyncHandler returns an AsyncHandler that wraps h and starts its worker.
+// Close must be called to drain the queue before the program exits.
+func NewAsyncHandler(h slog.Handler, opts AsyncOptions) *AsyncHandler {
+	if h == nil {
+		panic("nil Handler")
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+
+	c := &asyncCore{
+		opts:  opts,
+		queue: make(chan asyncItem, opts.QueueSize),
+		done:  make(chan struct{}),
+	}
+	if f, ok := h.(interface{ Flush() error }); ok {
+		c.flusher = f.Flush
+	}
+	go c.run()
+
+	return &AsyncHandler{handler: h, core: c}
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (a *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return a.handler.Enabled(ctx, level)
+}
+
+// Handle queues r for the wrapped handler according to the overflow policy.
+func (a *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= LevelFatal.Level() {
+		// Nothing may be lost before the process exits
+		_ = a.Flush()
+		return a.handler.Handle(ctx, r)
+	}
+
+	// Resolve the source position and the stack while the call site is on the stack
+	if !pcResolved(ctx) {
+		r.PC = a.callerPC(r.PC)
+	}
+	ctx = a.captureStack(ctx, r)
+
+	// Detach from the caller, who may cancel ctx or reuse r once we return
+	item := asyncItem{h: a.handler, ctx: context.WithoutCancel(ctx), r: r.Clone()}
+	a.core.enqueue(item)
+	return nil
+}
+
+// callerPC resolves the source position of the wrapped handler, if it does.
+func (a *AsyncHandler) callerPC(pc uintptr) uintptr {
+	if c, ok := a.handler.(callerResolver); ok {
+		return c.callerPC(pc)
+	}
+	return pc
+}
+
+// captureStack captures the stack of r for the wrapped handler, if it writes one.
+func (a *AsyncHandler) captureStack(ctx context.Context, r slog.Record) context.Context {
+	if c, ok := a.handler.(stackCapturer); ok {
+		return c.captureStack(ctx, r)
+	}
+	return ctx
+}
+
+// runFatal runs the fatal hook of the wrapped handler, if it has one.
+func (a *AsyncHandler) runFatal(ctx context.Context, r slog.Record) int {
+	if f, ok := a.handler.(fatalRunner); ok {
+		return f.runFatal(ctx, r)
+	}
+	return -1
+}
+
+// WithAttrs returns an AsyncHandler sharing the queue of a whose
+// wrapped handler has the given attributes.
+func (a *AsyncHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	return &AsyncHandler{handler: a.handler.WithAttrs(as), core: a.core}
+}
+
+// WithGroup returns an AsyncHandler sharing the queue of a whose
+// wrapped handler has the given group.
+func (a *AsyncHandler) WithGroup(name string) slog.Handler {
+	return &AsyncHandler{handler: a.handler.WithGroup(name), core: a.core}
+}
+
+// Flush waits until all records queued so far have been handled
+// and flushes the wrapped handler if it buffers output.
+func (a *AsyncHandler) Flush() error {
+	flushed := make(chan struct{})
+
+	a.core.mu.RLock()
+	if a.core.closed {
+		a.core.mu.RUnlock()
+		return nil
+	}
+	a.core.queue <- asyncItem{flushed: flushed}
+	a.core.mu.RUnlock()
+
+	<-flushed
+	return nil
+}
+
+// Close handles the remaining queued records and stops the worker.
+// Records logged after Close are dropped.
+func (a *AsyncHandler) Close() error {
+	a.core.mu.Lock()
+	if !a.core.closed {
+		a.core.closed = true
+		close(a.core.queue)
+	}
+	a.core.mu.Unlock()
+
+	<-a.core.done
+	return nil
+}
+
+// Dropped returns the number of records dropped because the queue was full
+// or the handler was closed.
+func (a *AsyncHandler) Dropped() uint64 {
+	return a.core.dropped.Load()
+}
+
+// Len returns the number of records waiting in the queue.
+func (a *AsyncHandler) Len() int {
+	return len(a.core.queue)
+}
+
+func (c *asyncCore) enqueue(item asyncItem) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		c.dropped.Add(1)
+		return
+	}
+
+	policy := c.opts.Overflow
+	if policy == OverflowKeepErrors {
+		if item.r.Level >= LevelError.Level() {
+			policy = OverflowBlock
+		} else {
+			policy = OverflowDropNewest
+		}
+	}
+
+	switch policy {
+	case OverflowDropNewest:
+		select {
+		case c.queue <- item:
+		default:
+			c.dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		c.dropMu.Lock()
+		defer c.dropMu.Unlock()
+		for {
+			select {
+			case c.queue <- item:
+				return
+			default:
+			}
+			select {
+			case old := <-c.queue:
+				if old.flushed != nil {
+					// Flush requests are never dropped
+					c.queue <- old
+					continue
+				}
+				c.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		c.queue <- item
+	}
+}
+
+// run handles queued records until the queue is closed.
+func (c *asyncCore) run() {
+	defer close(c.done)
+
+	var tick <-chan time.Time
+	if c.opts.FlushInterval > 0 {
+		ticker := time.NewTicker(c.opts.FlushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case item, ok := <-c.queue:
+			if !ok {
+				c.flush()
+				return
+			}
+			if item.flushed != nil {
+				c.flush()
+				close(item.flushed)
+				continue
+			}
+			if err := item.h.Handle(item.ctx, item.r); err != nil {
+				c.report(err)
+			}
+		case <-tick:
+			c.flush()
+		}
+	}
+}
+
+func (c *asyncCore) flush() {
+	if c.flusher == nil {
+		return
+	}
+	if err := c.flusher(); err != nil {
+		c.report(err)
+	}
+}
+
+func (c *asyncCore) report(err error) {
+	if c.opts.OnError != nil {
+		c.opts.OnError(err)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateWriter blocks every write until the gate is opened. The started
+// channel is closed once the first write reached the gate.
+type gateWriter struct {
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gateWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func newGatedAsync(policy OverflowPolicy) (*AsyncHandler, *gateWriter) {
+	w := &gateWriter{gate: make(chan struct{}), started: make(chan struct{})}
+	h := HandlerOptions(WithWriter(w), WithBuiltinSort([]string{}))
+	return NewAsyncHandler(h, AsyncOptions{QueueSize: 2, Overflow: policy}), w
+}
+
+// fill logs the messages while the writer is blocked. The first one is
+// taken off the queue by the worker, the rest compete for the queue.
+func fill(a *AsyncHandler, w *gateWriter, level slog.Level, msgs ...string) {
+	log := slog.New(a)
+	log.Log(context.Background(), level, msgs[0])
+	<-w.started
+	for _, msg := range msgs[1:] {
+		log.Log(context.Background(), level, msg)
+	}
+}
+
+func TestAsyncHandler_DropNewest(t *testing.T) {
+	a, w := newGatedAsync(OverflowDropNewest)
+	fill(a, w, slog.LevelInfo, "1", "2", "3", "4")
+	close(w.gate)
+	_ = a.Close()
+
+	if got, want := w.String(), "1\n2\n3\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if a.Dropped() != 1 {
+		t.Errorf("dropped %d, want 1", a.Dropped())
+	}
+}
+
+func TestAsyncHandler_DropOldest(t *testing.T) {
+	a, w := newGatedAsync(OverflowDropOldest)
+	fill(a, w, slog.LevelInfo, "1", "2", "3", "4", "5")
+	close(w.gate)
+	_ = a.Close()
+
+	if got, want := w.String(), "1\n4\n5\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if a.Dropped() != 2 {
+		t.Errorf("dropped %d, want 2", a.Dropped())
+	}
+}
+
+func TestAsyncHandler_KeepErrors(t *testing.T) {
+	a, w := newGatedAsync(OverflowKeepErrors)
+	fill(a, w, slog.LevelInfo, "1", "2", "3", "4")
+
+	done := make(chan struct{})
+	go func() {
+		slog.New(a).Error("5")
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("ERROR record was not blocked by the full queue")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(w.gate)
+	<-done
+	_ = a.Close()
+
+	if got, want := w.String(), "1\n2\n3\n5\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAsyncHandler_Flush(t *testing.T) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	h := HandlerOptions(WithWriter(bw), WithBuiltinSort([]string{}))
+	a := NewAsyncHandler(h, AsyncOptions{})
+	defer a.Close()
+
+	slog.New(a).With("k", "v").Info("hello")
+	if err := a.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := buf.String(), "hello | k=v\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAsyncHandler_FlushInterval(t *testing.T) {
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(lockedWriter{&mu, &buf})
+	h := HandlerOptions(WithWriter(bw), WithBuiltinSort([]string{}))
+	a := NewAsyncHandler(h, AsyncOptions{FlushInterval: time.Millisecond})
+	defer a.Close()
+
+	slog.New(a).Info("hello")
+	for i := 0; i < 1000; i++ {
+		mu.Lock()
+		got := buf.String()
+		mu.Unlock()
+		if strings.Contains(got, "hello") {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("record was not flushed periodically")
+}
+
+type lockedWriter struct {
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+}
+
+func (w lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
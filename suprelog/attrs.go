@@ -0,0 +1,129 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"log/slog"
+	"slices"
+)
+
+// DupKey is the policy applied when a record carries the same attribute
+// key more than once within the same group.
+type DupKey int
+
+// Duplicate key policies.
+const (
+	DupKeyLastWins  DupKey = iota // keep the position of the first, the value of the last
+	DupKeyFirstWins               // keep the first attribute, drop the later ones
+	DupKeyKeepAll                 // keep every attribute in call order
+)
+
+// nestAttrs wraps as in the given groups, outermost first.
+func nestAttrs(groups []string, as []slog.Attr) []slog.Attr {
+	for i := len(groups) - 1; i >= 0; i-- {
+		as = []slog.Attr{{Key: groups[i], Value: slog.GroupValue(as...)}}
+	}
+	return as
+}
+
+// mergeAttr resolves a and appends it to dst in call order. Empty attributes
+// and empty groups are ignored, groups with an empty key are inlined and
+// groups with the same key are merged. Duplicate keys follow the policy dup.
+func mergeAttr(dst []slog.Attr, a slog.Attr, dup DupKey) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return dst
+	}
+
+	idx := indexKey(dst, a.Key)
+
+	if a.Value.Kind() == slog.KindGroup {
+		as := a.Value.Group()
+		if a.Key == "" {
+			for _, ga := range as {
+				dst = mergeAttr(dst, ga, dup)
+			}
+			return dst
+		}
+
+		// Merge into an earlier group with the same key
+		var sub []slog.Attr
+		if idx >= 0 && dst[idx].Value.Kind() == slog.KindGroup {
+			sub = dst[idx].Value.Group()
+		} else {
+			idx = -1
+		}
+		for _, ga := range as {
+			sub = mergeAttr(sub, ga, dup)
+		}
+		if len(sub) == 0 {
+			return dst
+		}
+		a.Value = slog.GroupValue(sub...)
+		if idx >= 0 {
+			dst[idx] = a
+			return dst
+		}
+		return append(dst, a)
+	}
+
+	if idx >= 0 {
+		switch dup {
+		case DupKeyLastWins:
+			dst[idx] = a
+			return dst
+		case DupKeyFirstWins:
+			return dst
+		}
+	}
+	return append(dst, a)
+}
+
+// indexKey returns the index of the first attribute in as with the given key, or -1.
+func indexKey(as []slog.Attr, key string) int {
+	for i, a := range as {
+		if a.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// replaceAttrs returns as with ReplaceAttr applied to every attribute that
+// is not a group, given the path of the groups it is in. Attributes whose
+// key is replaced with an empty one and groups left empty are dropped.
+func (h *Handler) replaceAttrs(groups []string, as []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, 0, len(as))
+	for _, a := range as {
+		if a.Value.Kind() == slog.KindGroup {
+			sub := h.replaceAttrs(append(slices.Clip(groups), a.Key), a.Value.Group())
+			if len(sub) > 0 {
+				out = append(out, slog.Attr{Key: a.Key, Value: slog.GroupValue(sub...)})
+			}
+			continue
+		}
+		a = h.replaceAttr(groups, a)
+		if a.Key == "" {
+			continue
+		}
+		a.Value = a.Value.Resolve()
+		out = append(out, a)
+	}
+	return out
+}
+
+// flatAttrs resolves the values of as in place and reports whether the
+// attributes are neither empty nor groups and have distinct keys, so that
+// merging them would leave them unchanged.
+func flatAttrs(as []slog.Attr) bool {
+	for i := range as {
+		as[i].Value = as[i].Value.Resolve()
+		a := as[i]
+		if a.Key == "" || a.Value.Kind() == slog.KindGroup || indexKey(as[:i], a.Key) >= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package benchmarks
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pokeyaro/gopkg/suprelog"
+)
+
+// newClassic returns a Classic writing all the built-in fields, as the
+// default handler does, in the given type mode.
+func newClassic(typ string) *suprelog.Classic {
+	return suprelog.NewClassic(suprelog.HandlerOptions(
+		suprelog.WithWriter(io.Discard),
+		suprelog.WithBuiltinSort([]string{suprelog.FieldTime, suprelog.FieldLevel, suprelog.FieldPos}),
+		suprelog.WithLogLevel(suprelog.LevelInfo),
+		suprelog.WithMode(suprelog.NewMode().SetTyp(typ)),
+	))
+}
+
+// The common field types of Classic are expected to log without allocating.
+func BenchmarkClassic_Fields(b *testing.B) {
+	fields := []struct {
+		name string
+		add  func(c suprelog.Classical) suprelog.Classical
+	}{
+		{"Str", func(c suprelog.Classical) suprelog.Classical { return c.Str("user", "john") }},
+		{"Int", func(c suprelog.Classical) suprelog.Classical { return c.Int("count", 42) }},
+		{"Int64", func(c suprelog.Classical) suprelog.Classical { return c.Int64("size", 1<<40) }},
+		{"Float64", func(c suprelog.Classical) suprelog.Classical { return c.Float64("ratio", 0.75) }},
+		{"Bool", func(c suprelog.Classical) suprelog.Classical { return c.Bool("ok", true) }},
+		{"Time", func(c suprelog.Classical) suprelog.Classical { return c.Time("at", _tenTimes[1]) }},
+		{"Err", func(c suprelog.Classical) suprelog.Classical { return c.Err(errExample) }},
+		{"Dur", func(c suprelog.Classical) suprelog.Classical { return c.Dur("elapsed", time.Second) }},
+	}
+
+	for _, typ := range []string{suprelog.ModeText, suprelog.ModeLogfmt, suprelog.ModeNdjson, suprelog.ModeJson} {
+		logger := newClassic(typ)
+		for _, f := range fields {
+			b.Run(typ+"/"+f.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for n := 0; n < b.N; n++ {
+					f.add(logger.Info()).Msg(getMessage(n)).Emit()
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkClassic_Disabled(b *testing.B) {
+	logger := newClassic(suprelog.ModeNdjson)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		logger.Debug().Str("user", "john").Int("count", 42).Msg(getMessage(n)).Emit()
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/pokeyaro/gopkg/suprelog/internal"
+)
+
+// Helper marks the calling function as a logging helper function.
+// When a Handler resolves the source position of a record, helper
+// functions are skipped, so the position points at the caller of the
+// helper, much like testing.T.Helper.
+//
+// Helpers and WithCallerSkip are applied by Handler, so they hold for the
+// records of an Entry, a Classic or a slog.Logger, also behind the wrappers
+// of this package, but not for other slog handlers. An AsyncHandler
+// resolves the position before queueing the record, except for the sinks
+// of a FanoutHandler it wraps, which ignore them.
+func Helper() {
+	internal.MarkHelper(1)
+}
+
+// emit builds a record for the call site of the logging method that
+// called emit and passes it to h. It must be called directly from the
+// exported logging methods so that the caller depth stays constant.
+func emit(ctx context.Context, h slog.Handler, l Level, msg string, args ...any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !h.Enabled(ctx, l.Level()) {
+		return
+	}
+
+	// skip [emit, logging method]
+	r := slog.NewRecord(time.Now(), l.Level(), msg, internal.CallerPC(2))
+	r.Add(args...)
+	_ = h.Handle(ctx, r)
+}
+
+// emitAttrs is emit with attributes instead of key-value pairs.
+// It must be called directly from the exported logging methods.
+func emitAttrs(ctx context.Context, h slog.Handler, l Level, msg string, attrs []slog.Attr) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !h.Enabled(ctx, l.Level()) {
+		return
+	}
+
+	// skip [emitAttrs, logging method]
+	r := slog.NewRecord(time.Now(), l.Level(), msg, internal.CallerPC(2))
+	r.AddAttrs(attrs...)
+	_ = h.Handle(ctx, r)
+}
+
+// callerResolver is implemented by handlers that resolve the source
+// position of a record themselves, see Handler.callerPC.
+type callerResolver interface {
+	callerPC(pc uintptr) uintptr
+}
+
+// resolvedPCKey marks a context whose record has its source position
+// resolved already, e.g. a record kept by a DedupHandler and written again
+// later, which may happen while its call site is on the stack once more.
+type resolvedPCKey struct{}
+
+func withResolvedPC(ctx context.Context) context.Context {
+	return context.WithValue(ctx, resolvedPCKey{}, true)
+}
+
+func pcResolved(ctx context.Context) bool {
+	resolved, _ := ctx.Value(resolvedPCKey{}).(bool)
+	return resolved
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"testing"
+)
+
+func newPosLogger(buf *bytes.Buffer, funcs ...HandlerFunc) Logger {
+	funcs = append([]HandlerFunc{WithWriter(buf), WithBuiltinSort([]string{FieldPos})}, funcs...)
+	return HandlerOptions(funcs...).InitLogger()
+}
+
+func helperLog(log Logger) {
+	Helper()
+	log.Info("from helper")
+}
+
+func wrapperLog(log Logger) {
+	log.Info("from wrapper")
+}
+
+func slogWrapperLog(log *slog.Logger) {
+	log.Info("from wrapper")
+}
+
+func slogHelperLog(log *slog.Logger) {
+	Helper()
+	log.Info("from helper")
+}
+
+func currentLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+func TestEntry_Position(t *testing.T) {
+	var buf bytes.Buffer
+	log := newPosLogger(&buf)
+
+	log.Info("hello")
+	line := currentLine() - 1
+
+	if got, want := buf.String(), fmt.Sprintf("suprelog/caller_test.go:%d | hello\n", line); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEntry_PositionFuncName(t *testing.T) {
+	var buf bytes.Buffer
+	log := newPosLogger(&buf, WithFuncName(true))
+
+	log.Info("hello")
+	line := currentLine() - 1
+
+	want := fmt.Sprintf("suprelog/caller_test.go:%d suprelog.TestEntry_PositionFuncName | hello\n", line)
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHelper(t *testing.T) {
+	var buf bytes.Buffer
+	log := newPosLogger(&buf)
+
+	helperLog(log)
+	line := currentLine() - 1
+
+	if got, want := buf.String(), fmt.Sprintf("suprelog/caller_test.go:%d | from helper\n", line); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	var buf bytes.Buffer
+	log := newPosLogger(&buf, WithCallerSkip(1))
+
+	wrapperLog(log)
+	line := currentLine() - 1
+
+	if got, want := buf.String(), fmt.Sprintf("suprelog/caller_test.go:%d | from wrapper\n", line); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandler_PositionFromSlog(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{FieldPos}))
+
+	slog.New(h).Info("hello")
+	line := currentLine() - 1
+
+	if got, want := buf.String(), fmt.Sprintf("suprelog/caller_test.go:%d | hello\n", line); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithCallerSkip_Wrapped(t *testing.T) {
+	wrappers := map[string]func(h slog.Handler) slog.Handler{
+		"fanout":   func(h slog.Handler) slog.Handler { return NewFanoutHandler(h) },
+		"async":    func(h slog.Handler) slog.Handler { return NewAsyncHandler(h, AsyncOptions{}) },
+		"sampling": func(h slog.Handler) slog.Handler { return NewSamplingHandler(h, SamplingOptions{}) },
+		"dedup":    func(h slog.Handler) slog.Handler { return NewDedupHandler(h, DedupOptions{}) },
+	}
+	for name, wrap := range wrappers {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := wrap(HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{FieldPos}), WithCallerSkip(1)))
+			log := NewEntry(h)
+
+			wrapperLog(log)
+			line := currentLine() - 1
+
+			if c, ok := h.(interface{ Close() error }); ok {
+				_ = c.Close()
+			}
+			if got, want := buf.String(), fmt.Sprintf("suprelog/caller_test.go:%d | from wrapper\n", line); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestWithCallerSkip_Once(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewEntry(HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{FieldPos}), WithCallerSkip(1)))
+
+	wrapperLog(log.Once("test-caller-skip-once"))
+	line := currentLine() - 1
+
+	if got, want := buf.String(), fmt.Sprintf("suprelog/caller_test.go:%d | from wrapper\n", line); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithCallerSkip_Slog(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{FieldPos}), WithCallerSkip(1)))
+
+	slogWrapperLog(log)
+	line := currentLine() - 1
+
+	if got, want := buf.String(), fmt.Sprintf("suprelog/caller_test.go:%d | from wrapper\n", line); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHelper_Slog(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{FieldPos})))
+
+	slogHelperLog(log)
+	line := currentLine() - 1
+
+	if got, want := buf.String(), fmt.Sprintf("suprelog/caller_test.go:%d | from helper\n", line); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEntry_PositionEncoded(t *testing.T) {
+	for _, tt := range []struct {
+		typ, want string
+	}{
+		{ModeNdjson, `{"source":"suprelog/caller_test.go:%d suprelog.TestEntry_PositionEncoded","msg":"hello"}` + "\n"},
+		{ModeLogfmt, `source="suprelog/caller_test.go:%d suprelog.TestEntry_PositionEncoded" msg=hello` + "\n"},
+	} {
+		var buf bytes.Buffer
+		log := newPosLogger(&buf, WithFuncName(true), WithMode(NewMode().SetTyp(tt.typ)))
+
+		log.Info("hello")
+		line := currentLine() - 1
+
+		if got, want := buf.String(), fmt.Sprintf(tt.want, line); got != want {
+			t.Errorf("%s: got %q, want %q", tt.typ, got, want)
+		}
+	}
+}
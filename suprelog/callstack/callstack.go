@@ -0,0 +1,99 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package callstack captures the call stacks written by suprelog and
+// go-logger, and shortens the files of their frames the same way.
+package callstack
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Stack returns the frames of the calling goroutine from the one of the
+// call site pc outwards, without the frames of the runtime that started
+// the goroutine. It returns nil if pc is not on the stack, e.g. when the
+// record is handled on another goroutine.
+func Stack(pc uintptr) []runtime.Frame {
+	if pc == 0 {
+		return nil
+	}
+	site, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return frames(callers(1), &site)
+}
+
+// Callers returns the frames of the calling goroutine, skipping the given
+// number of frames above the caller of Callers, without the frames of the
+// runtime that started the goroutine.
+func Callers(skip int) []runtime.Frame {
+	return frames(callers(skip+1), nil)
+}
+
+// callers returns the program counters of the calling goroutine,
+// skipping the given number of frames above the caller of callers.
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, 64)
+	for {
+		// skip [runtime.Callers, callers]
+		n := runtime.Callers(skip+2, pcs)
+		if n < len(pcs) {
+			return pcs[:n]
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+}
+
+// frames returns the frames of pcs, starting at site if it is not nil.
+func frames(pcs []uintptr, site *runtime.Frame) []runtime.Frame {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	var stack []runtime.Frame
+	it := runtime.CallersFrames(pcs)
+	for more := true; more; {
+		var frame runtime.Frame
+		frame, more = it.Next()
+		if site != nil && stack == nil && (frame.Function != site.Function || frame.File != site.File || frame.Line != site.Line) {
+			continue
+		}
+		if frame.Function == "runtime.main" || frame.Function == "runtime.goexit" {
+			break
+		}
+		stack = append(stack, frame)
+	}
+	return stack
+}
+
+// goroot is the source directory of the standard library, found from
+// the file of a runtime function so that it also holds for binaries
+// built with -trimpath.
+var goroot = sync.OnceValue(func() string {
+	fn := runtime.FuncForPC(reflect.ValueOf(runtime.Gosched).Pointer())
+	if fn == nil {
+		return ""
+	}
+	file, _ := fn.FileLine(fn.Entry())
+	if i := strings.LastIndex(file, "runtime/"); i >= 0 {
+		return file[:i]
+	}
+	return ""
+})
+
+// ShortFile returns file relative to the standard library source directory
+// or to the module cache, e.g. "net/http/server.go" or
+// "github.com/goccy/go-json@v0.10.2/json.go", and reports whether it is
+// under either of them.
+func ShortFile(file string) (string, bool) {
+	if root := goroot(); root != "" && strings.HasPrefix(file, root) {
+		return file[len(root):], true
+	}
+	const modCache = "/pkg/mod/"
+	if i := strings.Index(file, modCache); i >= 0 {
+		return file[i+len(modCache):], true
+	}
+	return file, false
+}
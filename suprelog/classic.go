@@ -8,12 +8,13 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"strconv"
-
-	"github.com/pokeyaro/gopkg/suprelog/internal/buffer"
+	"sync"
+	"time"
 )
 
 // Classical interface is inspired by the internal go_logger library.
+// Fields become attributes of the record, Msg and Msgf set its message,
+// and Emit or EmitCtx send it to the handler of the Classic.
 type Classical interface {
 	Trace() Classical
 	Debug() Classical
@@ -23,25 +24,59 @@ type Classical interface {
 	Error() Classical
 	Fatal() Classical
 
-	Str(format string, a ...any) Classical
-	Int(i int) Classical
+	Str(key, val string) Classical
+	Strs(key string, vals []string) Classical
+	Int(key string, i int) Classical
+	Int64(key string, i int64) Classical
+	Float64(key string, f float64) Classical
+	Bool(key string, b bool) Classical
+	Dur(key string, d time.Duration) Classical
+	Time(key string, t time.Time) Classical
 	Err(err error) Classical
-	Obj(obj any) Classical
+	Any(key string, val any) Classical
+	Dict(key string, dict *Dict) Classical
 	Ctx(ctx context.Context, contextKey string) Classical
 
+	Msg(msg string) Classical
+	Msgf(format string, a ...any) Classical
+
 	Emit()
+	EmitCtx(ctx context.Context)
 }
 
+// KeyError is the key of the error added by Err.
+const KeyError = "error"
+
 // Classic represents a logger in classical style,
 // inspired by the internal go_logger library.
+//
+// The level methods start a record, an event taken from a pool that keeps
+// its fields as attributes and goes back to the pool once emitted, so the
+// event must not be used after Emit. A level the handler does not enable
+// starts a nil event, whose methods do nothing.
 type Classic struct {
 	handler slog.Handler
-	buf     *buffer.Buffer
 	level   Level
+	ctx     context.Context
+	msg     string
+	fields  Dict
+	event   bool
+}
+
+// Pool of the events of all Classic loggers.
+var classicPool = sync.Pool{
+	New: func() any {
+		return &Classic{fields: Dict{attrs: make([]slog.Attr, 0, 8)}, event: true}
+	},
 }
 
 // Handler returns the slog handler associated with the Classic logger.
-func (c *Classic) Handler() slog.Handler { return c.handler }
+func (c *Classic) Handler() slog.Handler {
+	if c == nil {
+		return nil
+	}
+	return c.handler
+}
 
 // NewClassic creates a new Classic logger instance with the given handler.
 func NewClassic(h slog.Handler) *Classic {
@@ -51,15 +86,38 @@ func NewClassic(h slog.Handler) *Classic {
 	return &Classic{handler: h}
 }
 
-// Level sets the log level for the Classic logger.
+// Level starts a record at the given level, sent to the handler of c,
+// or returns nil if the handler does not enable the level.
 func (c *Classic) Level(l Level) *Classic {
-	if l.String() == "UNKNOWN" {
-		panic("Unknown log level")
+	if c == nil || !c.handler.Enabled(context.Background(), l.Level()) {
+		return nil
 	}
-	return &Classic{
-		buf:   nil,
-		level: l,
+	e := classicPool.Get().(*Classic)
+	e.handler = c.handler
+	e.level = l
+	return e
+}
+
+// record returns c if it is an event, or else starts a record at the
+// level of c, INFO for a logger created by NewClassic.
+func (c *Classic) record() *Classic {
+	if c == nil || c.event {
+		return c
 	}
+	return c.Level(c.level)
+}
+
+// free returns the event c to the pool.
+func (c *Classic) free() {
+	// To reduce peak allocation, return only events with few fields.
+	const maxFields = 64
+	if cap(c.fields.attrs) > maxFields {
+		return
+	}
+	clear(c.fields.attrs)
+	c.fields.attrs = c.fields.attrs[:0]
+	c.handler, c.ctx, c.msg = nil, nil, ""
+	classicPool.Put(c)
 }
 
 // Syntactic sugar methods for setting log levels.
@@ -101,49 +159,190 @@ func (c *Classic) Fatal() Classical {
 
 // Chain methods for adding log data.
 
-// Str appends a formatted string to the log message.
-func (c *Classic) Str(format string, a ...any) Classical {
-	c.delimiter().buf.WriteString(fmt.Sprintf(format, a...))
+// Str adds a string field.
+func (c *Classic) Str(key, val string) Classical {
+	if c = c.record(); c != nil {
+		c.fields.Str(key, val)
+	}
+	return c
+}
+
+// Strs adds a string slice field.
+func (c *Classic) Strs(key string, vals []string) Classical {
+	if c = c.record(); c != nil {
+		c.fields.Strs(key, vals)
+	}
+	return c
+}
+
+// Int adds an integer field.
+func (c *Classic) Int(key string, i int) Classical {
+	if c = c.record(); c != nil {
+		c.fields.Int(key, i)
+	}
+	return c
+}
+
+// Int64 adds a 64-bit integer field.
+func (c *Classic) Int64(key string, i int64) Classical {
+	if c = c.record(); c != nil {
+		c.fields.Int64(key, i)
+	}
 	return c
 }
 
-// Int appends an integer value to the log message.
-func (c *Classic) Int(i int) Classical {
-	c.delimiter().buf.WriteString(strconv.Itoa(i))
+// Float64 adds a floating-point field.
+func (c *Classic) Float64(key string, f float64) Classical {
+	if c = c.record(); c != nil {
+		c.fields.Float64(key, f)
+	}
 	return c
 }
 
-// Err appends an error value to the log message.
+// Bool adds a boolean field.
+func (c *Classic) Bool(key string, b bool) Classical {
+	if c = c.record(); c != nil {
+		c.fields.Bool(key, b)
+	}
+	return c
+}
+
+// Dur adds a duration field.
+func (c *Classic) Dur(key string, d time.Duration) Classical {
+	if c = c.record(); c != nil {
+		c.fields.Dur(key, d)
+	}
+	return c
+}
+
+// Time adds a time field.
+func (c *Classic) Time(key string, t time.Time) Classical {
+	if c = c.record(); c != nil {
+		c.fields.Time(key, t)
+	}
+	return c
+}
+
+// Err adds the error under KeyError, unless it is nil.
 func (c *Classic) Err(err error) Classical {
-	c.delimiter().buf.WriteError(err)
+	if c = c.record(); c != nil {
+		c.fields.Err(err)
+	}
+	return c
+}
+
+// Any adds a field of any value.
+func (c *Classic) Any(key string, val any) Classical {
+	if c = c.record(); c != nil {
+		c.fields.Any(key, val)
+	}
 	return c
 }
 
-// Obj appends an arbitrary object to the log message.
-func (c *Classic) Obj(obj any) Classical {
-	c.delimiter().buf.WriteString(fmt.Sprint(obj))
+// Dict adds the fields of dict as a nested object.
+func (c *Classic) Dict(key string, dict *Dict) Classical {
+	if c = c.record(); c != nil {
+		c.fields.Dict(key, dict)
+	}
 	return c
 }
 
-// Ctx appends the value associated with a context key to the log message.
+// Ctx adds the value associated with a context key as a field of that key.
+// The context is also passed to the handler, whose context extractors
+// add their attributes to the record.
 func (c *Classic) Ctx(ctx context.Context, contextKey string) Classical {
-	c.delimiter().buf.WriteString(fmt.Sprintf("%v", ctx.Value(contextKey)))
+	if c = c.record(); c != nil {
+		c.fields.Any(contextKey, ctx.Value(contextKey))
+		c.ctx = ctx
+	}
 	return c
 }
 
-// Emit sends the log message to the handler.
+// Msg sets the message of the record.
+func (c *Classic) Msg(msg string) Classical {
+	if c = c.record(); c != nil {
+		c.msg = msg
+	}
+	return c
+}
+
+// Msgf sets the message of the record to a formatted string.
+func (c *Classic) Msgf(format string, a ...any) Classical {
+	if c = c.record(); c != nil {
+		c.msg = fmt.Sprintf(format, a...)
+	}
+	return c
+}
+
+// Emit sends the record to the Classic's handler and frees the event.
+// It does nothing if no record was started.
 func (c *Classic) Emit() {
-	if c.buf == nil {
+	if c == nil || !c.event {
 		return
 	}
-	slog.Log(context.Background(), c.level.Level(), c.buf.String())
+	emitAttrs(c.ctx, c.handler, c.level, c.msg, c.fields.attrs)
+	c.free()
 }
 
-func (c *Classic) delimiter() *Classic {
-	if c.buf == nil {
-		c.buf = new(buffer.Buffer)
-	} else {
-		c.buf.WriteString(" - ")
+// EmitCtx sends the record to the Classic's handler with the given context
+// and frees the event. It does nothing if no record was started.
+func (c *Classic) EmitCtx(ctx context.Context) {
+	if c == nil || !c.event {
+		return
 	}
-	return c
+	emitAttrs(ctx, c.handler, c.level, c.msg, c.fields.attrs)
+	c.free()
+}
+
+// Dict is a set of fields nested under a key by Classic.Dict.
+type Dict struct {
+	attrs []slog.Attr
+}
+
+// NewDict returns an empty Dict.
+func NewDict() *Dict { return new(Dict) }
+
+// Str adds a string field.
+func (d *Dict) Str(key, val string) *Dict { return d.add(slog.String(key, val)) }
+
+// Strs adds a string slice field.
+func (d *Dict) Strs(key string, vals []string) *Dict { return d.add(slog.Any(key, vals)) }
+
+// Int adds an integer field.
+func (d *Dict) Int(key string, i int) *Dict { return d.add(slog.Int(key, i)) }
+
+// Int64 adds a 64-bit integer field.
+func (d *Dict) Int64(key string, i int64) *Dict { return d.add(slog.Int64(key, i)) }
+
+// Float64 adds a floating-point field.
+func (d *Dict) Float64(key string, f float64) *Dict { return d.add(slog.Float64(key, f)) }
+
+// Bool adds a boolean field.
+func (d *Dict) Bool(key string, b bool) *Dict { return d.add(slog.Bool(key, b)) }
+
+// Dur adds a duration field.
+func (d *Dict) Dur(key string, dur time.Duration) *Dict { return d.add(slog.Duration(key, dur)) }
+
+// Time adds a time field.
+func (d *Dict) Time(key string, t time.Time) *Dict { return d.add(slog.Time(key, t)) }
+
+// Err adds the error under KeyError, unless it is nil.
+func (d *Dict) Err(err error) *Dict {
+	if err == nil {
+		return d
+	}
+	return d.add(slog.Any(KeyError, err))
+}
+
+// Any adds a field of any value.
+func (d *Dict) Any(key string, val any) *Dict { return d.add(slog.Any(key, val)) }
+
+// Dict adds the fields of dict as a nested object.
+func (d *Dict) Dict(key string, dict *Dict) *Dict {
+	return d.add(slog.Attr{Key: key, Value: slog.GroupValue(dict.attrs...)})
+}
+
+func (d *Dict) add(a slog.Attr) *Dict {
+	d.attrs = append(d.attrs, a)
+	return d
 }
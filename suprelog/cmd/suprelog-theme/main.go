@@ -0,0 +1,106 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Command suprelog-theme previews color themes by printing a record at
+// every level with each of them.
+//
+// Usage:
+//
+//	suprelog-theme [-theme arco,okabe-ito] [-file theme.json] [-profile truecolor]
+//
+// Without -theme, all registered themes are printed, including the one of
+// -file. The profile is one of auto, 16, 256 and truecolor.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pokeyaro/gopkg/suprelog"
+)
+
+var profiles = map[string]suprelog.ColorProfile{
+	"auto":      suprelog.ColorAuto,
+	"16":        suprelog.Color16,
+	"256":       suprelog.Color256,
+	"truecolor": suprelog.ColorTrueColor,
+}
+
+func main() {
+	themeList := flag.String("theme", "", "comma-separated themes to preview, all if empty")
+	file := flag.String("file", "", "JSON theme file to load")
+	profileName := flag.String("profile", "truecolor", "color profile: auto, 16, 256 or truecolor")
+	flag.Parse()
+
+	profile, ok := profiles[*profileName]
+	if !ok {
+		fail(fmt.Errorf("unknown profile %q", *profileName))
+	}
+
+	names := suprelog.Themes()
+	if *file != "" {
+		name, err := suprelog.LoadThemeFile(*file)
+		if err != nil {
+			fail(err)
+		}
+		names = suprelog.Themes()
+		if *themeList == "" {
+			*themeList = name
+		}
+	}
+	if *themeList != "" {
+		names = strings.Split(*themeList, ",")
+	}
+
+	for _, name := range names {
+		cs, err := suprelog.LookupTheme(strings.TrimSpace(name))
+		if err != nil {
+			fail(err)
+		}
+		cs.Rules = suprelog.StatusCodeRules("status")
+		fmt.Printf("%s\n", name)
+		preview(cs, profile)
+		fmt.Println()
+	}
+}
+
+// preview prints a record at every named level with the color scale cs.
+func preview(cs *suprelog.ColorScale, profile suprelog.ColorProfile) {
+	// Records are logged at INFO and shown at the previewed level,
+	// so that FATAL does not exit.
+	var shown suprelog.Level
+	h := suprelog.HandlerOptions(
+		suprelog.WithWriter(os.Stdout),
+		suprelog.WithLogLevel(suprelog.LevelInfo),
+		suprelog.WithBuiltinSort([]string{suprelog.FieldTime, suprelog.FieldLevel}),
+		suprelog.WithTimeFormat(time.TimeOnly),
+		suprelog.WithColorful(true),
+		suprelog.WithColorProfile(profile),
+		suprelog.WithColorScale(cs),
+		suprelog.WithReplaceAttr(func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == suprelog.KeyLevel {
+				a.Value = slog.AnyValue(shown)
+			}
+			return a
+		}),
+	)
+
+	statuses := []int{200, 302, 404, 500}
+	logger := slog.New(h)
+	for i, spec := range suprelog.Levels() {
+		shown = spec.Level
+		logger.Log(context.Background(), slog.LevelInfo, "the quick brown fox",
+			"status", statuses[i%len(statuses)], "latency", 42*time.Millisecond)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "suprelog-theme:", err)
+	os.Exit(2)
+}
@@ -5,22 +5,87 @@
 package suprelog
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ColorItem defines the color information for a specific log level.
 type ColorItem struct {
-	Level string // Log level associated with the color.
-	RGB   []int  // RGB color values.
-	Hex   string // Hexadecimal color code.
+	Level string `json:"level"`         // Log level associated with the color.
+	RGB   []int  `json:"rgb,omitempty"` // RGB color values.
+	Hex   string `json:"hex,omitempty"` // Hexadecimal color code.
 }
 
 // ColorScale defines a set of colors to be used in log output based on log levels.
+// The text colors of the other parts of a text record are hexadecimal color
+// codes, an empty one leaves the part in the default color of the terminal.
 type ColorScale struct {
-	IsRGB  bool        // Indicates whether RGB mode is used.
-	Colors []ColorItem // List of color information for different log levels.
+	IsRGB  bool        `json:"is_rgb"` // Indicates whether RGB mode is used.
+	Colors []ColorItem `json:"colors"` // List of color information for different log levels.
+
+	Time    string      `json:"time,omitempty"`    // Text color of the timestamp.
+	Pos     string      `json:"pos,omitempty"`     // Text color of the source position.
+	Message string      `json:"message,omitempty"` // Text color of the message.
+	Key     string      `json:"key,omitempty"`     // Text color of attribute keys.
+	Value   string      `json:"value,omitempty"`   // Text color of attribute values without a matching rule.
+	Rules   []ColorRule `json:"-"`                 // Text colors of attribute values by rule, the first match wins.
+}
+
+// ColorRule colors the attribute values it matches.
+type ColorRule struct {
+	Key   string                  // Dotted key of the attributes, any key if empty.
+	Match func(v slog.Value) bool // Reports whether the value is colored.
+	Color string                  // Hexadecimal text color of matching values.
+}
+
+// StatusCodeRules returns rules coloring the HTTP status codes of key:
+// green for 2xx, cyan for 3xx, yellow for 4xx and red for 5xx.
+func StatusCodeRules(key string) []ColorRule {
+	class := func(lo int) func(v slog.Value) bool {
+		return func(v slog.Value) bool {
+			var code int64
+			switch v.Kind() {
+			case slog.KindInt64:
+				code = v.Int64()
+			case slog.KindUint64:
+				code = int64(v.Uint64())
+			default:
+				n, err := strconv.ParseInt(v.String(), 10, 64)
+				if err != nil {
+					return false
+				}
+				code = n
+			}
+			return code >= int64(lo) && code < int64(lo+100)
+		}
+	}
+	return []ColorRule{
+		{Key: key, Match: class(200), Color: "#00B42A"},
+		{Key: key, Match: class(300), Color: "#14C9C9"},
+		{Key: key, Match: class(400), Color: "#FF7D00"},
+		{Key: key, Match: class(500), Color: "#F53F3F"},
+	}
+}
+
+// SlowRule returns a rule coloring the durations of key above threshold.
+// Values are durations or strings parsed by time.ParseDuration.
+func SlowRule(key string, threshold time.Duration, color string) ColorRule {
+	return ColorRule{
+		Key: key,
+		Match: func(v slog.Value) bool {
+			if v.Kind() == slog.KindDuration {
+				return v.Duration() > threshold
+			}
+			d, err := time.ParseDuration(v.String())
+			return err == nil && d > threshold
+		},
+		Color: color,
+	}
 }
 
 // NewColorScale creates a new instance of ColorScale with default color settings.
@@ -74,17 +139,25 @@ func NewColorScale() *ColorScale {
 				Hex:   "#681752",
 			},
 		},
+		// 星灰 Star Gray, 碧青 Jade Green, 蛙绿 Frog Green, 麦秆黄 Straw Yellow
+		Time:  "#b2bbbe",
+		Pos:   "#5cb3cc",
+		Key:   "#45b787",
+		Value: "#e2c17c",
 	}
 }
 
 const (
-	arco = "arco"
-	ant  = "ant"
-	ele  = "element"
+	chinese    = "chinese"
+	arco       = "arco"
+	ant        = "ant"
+	ele        = "element"
+	okabeIto   = "okabe-ito"
+	hiContrast = "high-contrast"
 )
 
-// ColorTheme creates a new ColorScale instance based on a specified theme.
-func ColorTheme(theme string) *ColorScale {
+// builtinTheme creates a new ColorScale instance based on a specified built-in theme.
+func builtinTheme(theme string) *ColorScale {
 	colorInit := new(ColorScale)
 
 	switch theme {
@@ -129,6 +202,11 @@ func ColorTheme(theme string) *ColorScale {
 					RGB:   []int{245, 49, 157},
 				},
 			},
+			// arco gray-6, arcoblue-5, cyan-6 and gold-6
+			Time:  "#86909c",
+			Pos:   "#4080ff",
+			Key:   "#14c9c9",
+			Value: "#f7ba1e",
 		}
 	case ant:
 		// refer to https://ant.design/
@@ -171,6 +249,11 @@ func ColorTheme(theme string) *ColorScale {
 					Hex:   "#531dab",
 				},
 			},
+			// ant gray-7, blue-6, cyan-6 and gold-7
+			Time:  "#8c8c8c",
+			Pos:   "#1677ff",
+			Key:   "#13c2c2",
+			Value: "#d48806",
 		}
 	case ele:
 		// refer to https://element-plus.org/
@@ -206,6 +289,97 @@ func ColorTheme(theme string) *ColorScale {
 					Hex:   "#909399",
 				},
 			},
+			// element info, primary, success and warning
+			Time:  "#909399",
+			Pos:   "#409EFF",
+			Key:   "#67C23A",
+			Value: "#E6A23C",
+		}
+	case okabeIto:
+		// Color-blind-safe palette of Okabe and Ito,
+		// refer to https://jfly.uni-koeln.de/color/
+		colorInit = &ColorScale{
+			IsRGB: false,
+			Colors: []ColorItem{
+				{
+					// gray
+					Level: "TRACE",
+					Hex:   "#999999",
+				},
+				{
+					// sky blue
+					Level: "DEBUG",
+					Hex:   "#56B4E9",
+				},
+				{
+					// blue
+					Level: "INFO",
+					Hex:   "#0072B2",
+				},
+				{
+					// bluish green
+					Level: "NOTICE",
+					Hex:   "#009E73",
+				},
+				{
+					// orange
+					Level: "WARN",
+					Hex:   "#E69F00",
+				},
+				{
+					// vermillion
+					Level: "ERROR",
+					Hex:   "#D55E00",
+				},
+				{
+					// reddish purple
+					Level: "FATAL",
+					Hex:   "#CC79A7",
+				},
+			},
+			Time:  "#999999",
+			Pos:   "#56B4E9",
+			Key:   "#009E73",
+			Value: "#E69F00",
+		}
+	case hiContrast:
+		// Saturated primaries for dark terminals and low vision
+		colorInit = &ColorScale{
+			IsRGB: false,
+			Colors: []ColorItem{
+				{
+					Level: "TRACE",
+					Hex:   "#C0C0C0",
+				},
+				{
+					Level: "DEBUG",
+					Hex:   "#00FFFF",
+				},
+				{
+					Level: "INFO",
+					Hex:   "#00FF00",
+				},
+				{
+					Level: "NOTICE",
+					Hex:   "#FFFFFF",
+				},
+				{
+					Level: "WARN",
+					Hex:   "#FFFF00",
+				},
+				{
+					Level: "ERROR",
+					Hex:   "#FF0000",
+				},
+				{
+					Level: "FATAL",
+					Hex:   "#FF00FF",
+				},
+			},
+			Time:  "#FFFFFF",
+			Pos:   "#00FFFF",
+			Key:   "#FFFF00",
+			Value: "#FFFFFF",
 		}
 	default:
 		// refer to http://zhongguose.com/
@@ -216,58 +390,165 @@ func ColorTheme(theme string) *ColorScale {
 }
 
 // getColoredLevel returns the formatted log level with ANSI color codes.
+// Levels without a name take the color of the next lower named level,
+// which comes from the color scale or else from the level registry.
+// Invalid colors leave the level uncolored.
 func (s *handleState) getColoredLevel(level string) string {
-	var colorCode string
+	scale := s.colors()
 
-	scale := s.h.colorScale
+	spec, known := LevelSpec{Name: level}, false
+	if l, err := ParseLevel(level); err == nil {
+		spec, known = currentLevels().base(l), true
+	}
 
+	var rgb []int
 	for _, item := range scale.Colors {
-		if level == strings.ToUpper(item.Level) {
-			if scale.IsRGB {
-				colorCode = rgbToAnsi(item.RGB...)
-			} else {
-				colorCode = hexToAnsi(item.Hex)
-			}
+		name := strings.ToUpper(item.Level)
+		if name == spec.Name || known && name == spec.Short {
+			rgb = item.rgb(scale.IsRGB)
 			break
 		}
 	}
+	if rgb == nil && spec.Color != "" {
+		rgb = hexToRGB(spec.Color)
+	}
+	if rgb == nil {
+		return fmt.Sprintf("[%s]", level)
+	}
 
-	return fmt.Sprintf("%s[%s]\033[0m", colorCode, level)
+	return fmt.Sprintf("%s%s[%s]\033[0m", rgbToAnsi(s.h.colorProfile, rgb...), contrastText(rgb...), level)
 }
 
-// rgbToAnsi converts RGB color values to ANSI color codes.
-func rgbToAnsi(num ...int) string {
-	if len(num) != 3 {
-		panic("invalid rgb color code")
+// rgb returns the RGB values of the color of the item, nil if it is invalid.
+func (item ColorItem) rgb(isRGB bool) []int {
+	if !isRGB {
+		return hexToRGB(item.Hex)
+	}
+	if validRGB(item.RGB) != nil {
+		return nil
+	}
+	return item.RGB
+}
+
+// contrastText returns the escape sequence of the black or white text,
+// whichever is more readable on the background color of the RGB values.
+func contrastText(rgb ...int) string {
+	if luminance(rgb[0], rgb[1], rgb[2]) > 0.179 {
+		return "\033[30m"
 	}
+	return "\033[97m"
+}
 
-	r := num[0]
-	g := num[1]
-	b := num[2]
+// luminance returns the relative luminance of an sRGB color, from 0 for black to 1 for white.
+// Black text has the better contrast above 0.179, white text below.
+func luminance(r, g, b int) float64 {
+	lin := func(c int) float64 {
+		v := float64(c) / 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*lin(r) + 0.7152*lin(g) + 0.0722*lin(b)
+}
 
-	closestColor := (r*6/256)*36 + (g*6/256)*6 + (b * 6 / 256)
-	return fmt.Sprintf("\033[48;5;%dm", 16+closestColor)
+// colorful reports whether the output of the handler is colored.
+func (s *handleState) colorful() bool {
+	return s.h.isColorful && s.h.colorProfile != ColorNone
 }
 
-// hexToAnsi converts Hexadecimal color codes to ANSI color codes.
-func hexToAnsi(hex string) string {
-	if len(hex) != 7 || hex[0] != '#' {
-		panic("invalid hexadecimal color code")
+// noColors is the color scale of handlers without one.
+var noColors ColorScale
+
+// colors returns the color scale of the handler.
+func (s *handleState) colors() *ColorScale {
+	if s.h.colorScale == nil {
+		return &noColors
 	}
+	return s.h.colorScale
+}
 
-	r, err := strconv.ParseInt(hex[1:3], 16, 0)
-	if err != nil {
-		panic(err)
+// appendColored writes str in the text color hex, if the output is colored.
+func (s *handleState) appendColored(hex, str string) {
+	on := s.startColor(hex)
+	s.buf.WriteString(str)
+	s.endColor(on)
+}
+
+// startColor starts text in the color hex and reports whether it did,
+// which it does only if the output is colored.
+func (s *handleState) startColor(hex string) bool {
+	if hex == "" || !s.colorful() {
+		return false
 	}
-	g, err := strconv.ParseInt(hex[3:5], 16, 0)
+	r, g, b, err := parseHex(hex)
 	if err != nil {
-		panic(err)
+		return false
+	}
+	s.buf.WriteString(s.h.colorProfile.foreground(r, g, b))
+	return true
+}
+
+// endColor ends the color started by startColor, if on.
+func (s *handleState) endColor(on bool) {
+	if on {
+		s.buf.WriteString("\033[0m")
+	}
+}
+
+// valueColor returns the text color of the value of the attribute with the dotted key.
+func (s *handleState) valueColor(key string, v slog.Value) string {
+	for _, rule := range s.colors().Rules {
+		if (rule.Key == "" || rule.Key == key) && rule.Match != nil && rule.Match(v) {
+			return rule.Color
+		}
 	}
-	b, err := strconv.ParseInt(hex[5:7], 16, 0)
+	return s.colors().Value
+}
+
+// rgbToAnsi converts RGB color values to ANSI color codes of the profile.
+func rgbToAnsi(p ColorProfile, num ...int) string {
+	if validRGB(num) != nil {
+		return ""
+	}
+	return p.background(num[0], num[1], num[2])
+}
+
+// hexToRGB converts Hexadecimal color codes to RGB color values, nil if the code is invalid.
+func hexToRGB(hex string) []int {
+	r, g, b, err := parseHex(hex)
 	if err != nil {
-		panic(err)
+		return nil
 	}
+	return []int{r, g, b}
+}
 
-	closestColor := (int(r)*6/256)*36 + (int(g)*6/256)*6 + (int(b) * 6 / 256)
-	return fmt.Sprintf("\033[48;5;%dm", 16+closestColor)
+// validRGB returns an error unless num are valid RGB color values.
+func validRGB(num []int) error {
+	if len(num) != 3 {
+		return fmt.Errorf("invalid rgb color code %v", num)
+	}
+	for _, v := range num {
+		if v < 0 || v > 255 {
+			return fmt.Errorf("invalid rgb color code %v", num)
+		}
+	}
+	return nil
+}
+
+// parseHex returns the RGB values of a hexadecimal color code such as "#1F2E3D".
+func parseHex(hex string) (r, g, b int, err error) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return 0, 0, 0, errors.New("invalid hexadecimal color code")
+	}
+
+	var rgb [3]int
+	for i := range rgb {
+		v, err := strconv.ParseUint(hex[1+2*i:3+2*i], 16, 8)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid hexadecimal color code %q", hex)
+		}
+		rgb[i] = int(v)
+	}
+	return rgb[0], rgb[1], rgb[2], nil
 }
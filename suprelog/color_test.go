@@ -0,0 +1,91 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestContrastText(t *testing.T) {
+	tests := []struct {
+		rgb      []int
+		expected string
+	}{
+		{[]int{255, 255, 255}, "\033[30m"},
+		{[]int{254, 215, 26}, "\033[30m"},
+		{[]int{18, 107, 174}, "\033[97m"},
+		{[]int{0, 0, 0}, "\033[97m"},
+	}
+
+	for _, tt := range tests {
+		if got := contrastText(tt.rgb...); got != tt.expected {
+			t.Errorf("%v: got %q, want %q", tt.rgb, got, tt.expected)
+		}
+	}
+}
+
+func TestColorScaleText(t *testing.T) {
+	scale := &ColorScale{
+		Colors:  []ColorItem{{Level: "INFO", Hex: "#FFFFFF"}},
+		Message: "#FF0000",
+		Key:     "#00FF00",
+		Rules: append(StatusCodeRules("status"),
+			SlowRule("latency", 100*time.Millisecond, "#FF00FF")),
+	}
+
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{FieldLevel}),
+		WithColorful(true),
+		WithColorProfile(Color16),
+		WithColorScale(scale),
+	)
+	slog.New(h).Info("done", "status", 503, "latency", 250*time.Millisecond, "ok", 1)
+
+	want := "\033[107m\033[30m[INFO]\033[0m | \033[91mdone\033[0m | " +
+		"\033[92mstatus\033[0m=\033[91m503\033[0m " +
+		"\033[92mlatency\033[0m=\033[95m250ms\033[0m " +
+		"\033[92mok\033[0m=1\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestColorRules(t *testing.T) {
+	status := StatusCodeRules("status")
+	tests := []struct {
+		value    slog.Value
+		expected string
+	}{
+		{slog.IntValue(204), "#00B42A"},
+		{slog.StringValue("302"), "#14C9C9"},
+		{slog.Uint64Value(404), "#FF7D00"},
+		{slog.IntValue(500), "#F53F3F"},
+		{slog.IntValue(600), ""},
+		{slog.StringValue("ok"), ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		for _, rule := range status {
+			if rule.Match(tt.value) {
+				got = rule.Color
+				break
+			}
+		}
+		if got != tt.expected {
+			t.Errorf("%v: got %q, want %q", tt.value, got, tt.expected)
+		}
+	}
+
+	slow := SlowRule("latency", time.Second, "#FF0000")
+	if !slow.Match(slog.StringValue("1.5s")) || slow.Match(slog.DurationValue(time.Second)) {
+		t.Error("SlowRule matched the wrong durations")
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Config is a declarative Handler configuration. It can be loaded from a
+// JSON file, from SUPRELOG_* environment variables and from command line
+// flags, applied in that order on top of NewConfig, and then turned into
+// a Handler with Build.
+type Config struct {
+	Level      Level    `json:"level"`       // Minimum level, e.g. "INFO"
+	VModule    string   `json:"vmodule"`     // Level overrides by source file, see ParseVModule
+	TimeFormat string   `json:"time_format"` // Layout or name of a time package layout, e.g. "DateTime"
+	Fields     []string `json:"fields"`      // Order of the built-in fields: time, level and position
+	LogMode    string   `json:"log_mode"`    // "simplify" or "detail"
+	TypMode    string   `json:"typ_mode"`    // "text", "json", "ndjson" or "logfmt"
+	Theme      string   `json:"theme"`       // Name of a registered color theme, see RegisterTheme
+	Colorful   bool     `json:"colorful"`    // Indicates whether the output is colored
+	Writers    []string `json:"writers"`     // "stdout", "stderr" or file names
+	ExitCode   int      `json:"exit_code"`   // Exit code of FATAL records
+}
+
+// Prefix of the environment variables read by LoadEnv.
+const envPrefix = "SUPRELOG_"
+
+// Names of the log modes in a Config.
+const (
+	logModeSimplify = "simplify"
+	logModeDetail   = "detail"
+)
+
+// Names of the standard writers in a Config.
+const (
+	writerStdout = "stdout"
+	writerStderr = "stderr"
+)
+
+// Named time layouts accepted as TimeFormat.
+var timeLayouts = map[string]string{
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"StampMilli":  time.StampMilli,
+}
+
+// NewConfig returns a Config with the defaults of HandlerOptions.
+func NewConfig() *Config {
+	return &Config{
+		Level:      LevelDebug,
+		TimeFormat: time.DateOnly,
+		Fields:     []string{FieldTime},
+		LogMode:    logModeSimplify,
+		TypMode:    ModeText,
+		Writers:    []string{writerStdout},
+		ExitCode:   1,
+	}
+}
+
+// LoadFile sets the fields present in the JSON file name. Unknown fields are errors.
+func (c *Config) LoadFile(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("suprelog: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("suprelog: %s: %w", name, err)
+	}
+	return nil
+}
+
+// LoadEnv sets the fields whose environment variables are set, e.g.
+// SUPRELOG_LEVEL=info or SUPRELOG_WRITERS=stdout,app.log. The variable of a
+// field is its JSON name in upper case, lists are comma-separated.
+func (c *Config) LoadEnv() error {
+	var errs []error
+	c.visit(func(key string, v flag.Value) {
+		s, ok := os.LookupEnv(envPrefix + strings.ToUpper(key))
+		if !ok {
+			return
+		}
+		if err := v.Set(s); err != nil {
+			errs = append(errs, fmt.Errorf("%s%s: %w", envPrefix, strings.ToUpper(key), err))
+		}
+	})
+	if len(errs) > 0 {
+		return fmt.Errorf("suprelog: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// RegisterFlags defines a flag for each field in fs, named after its JSON
+// name with the given prefix, e.g. -log.level with the prefix "log.".
+// The current values are the defaults of the flags.
+func (c *Config) RegisterFlags(fs *flag.FlagSet, prefix string) {
+	c.visit(func(key string, v flag.Value) {
+		fs.Var(v, prefix+key, "suprelog "+strings.ReplaceAll(key, "_", " "))
+	})
+}
+
+// visit calls fn with the JSON name and a flag.Value of each field.
+func (c *Config) visit(fn func(key string, v flag.Value)) {
+	fn("level", &c.Level)
+	fn("vmodule", (*stringValue)(&c.VModule))
+	fn("time_format", (*stringValue)(&c.TimeFormat))
+	fn("fields", (*listValue)(&c.Fields))
+	fn("log_mode", (*stringValue)(&c.LogMode))
+	fn("typ_mode", (*stringValue)(&c.TypMode))
+	fn("theme", (*stringValue)(&c.Theme))
+	fn("colorful", (*boolValue)(&c.Colorful))
+	fn("writers", (*listValue)(&c.Writers))
+	fn("exit_code", (*intValue)(&c.ExitCode))
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+func (v *stringValue) String() string     { return string(*v) }
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	*v = boolValue(b)
+	return err
+}
+func (v *boolValue) String() string   { return strconv.FormatBool(bool(*v)) }
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	*v = intValue(n)
+	return err
+}
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+// listValue is a comma-separated list, an empty string is the empty list.
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	*v = []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*v = append(*v, item)
+		}
+	}
+	return nil
+}
+func (v *listValue) String() string { return strings.Join(*v, ",") }
+
+// Validate reports all invalid fields of c.
+func (c *Config) Validate() error {
+	var errs []error
+	if _, err := ParseVModule(c.VModule); err != nil {
+		errs = append(errs, err)
+	}
+	if c.TimeFormat == "" {
+		errs = append(errs, errors.New("empty time format"))
+	}
+	for _, f := range c.Fields {
+		if f != FieldTime && f != FieldLevel && f != FieldPos {
+			errs = append(errs, fmt.Errorf("unknown field %q", f))
+		}
+	}
+	if c.LogMode != logModeSimplify && c.LogMode != logModeDetail {
+		errs = append(errs, fmt.Errorf("unknown log mode %q", c.LogMode))
+	}
+	if !slices.Contains([]string{ModeText, ModeJson, ModeNdjson, ModeLogfmt}, c.TypMode) {
+		errs = append(errs, fmt.Errorf("unknown typ mode %q", c.TypMode))
+	}
+	if c.Theme != "" && !knownTheme(c.Theme) {
+		errs = append(errs, fmt.Errorf("unknown theme %q", c.Theme))
+	}
+	if len(c.Writers) == 0 {
+		errs = append(errs, errors.New("no writers"))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("suprelog: invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// knownTheme reports whether name is a registered theme.
+func knownTheme(name string) bool {
+	return slices.Contains(Themes(), name)
+}
+
+// Build validates c and returns a Handler configured by it, along with
+// the files it writes to. Files among the writers are opened for appending
+// by a RotateWriter without limits, they must be closed by the caller.
+func (c *Config) Build() (*Handler, RotateWriters, error) {
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	rules, _ := ParseVModule(c.VModule)
+
+	timeFmt := c.TimeFormat
+	if layout, ok := timeLayouts[timeFmt]; ok {
+		timeFmt = layout
+	}
+
+	mode := NewMode().SetTyp(c.TypMode)
+	if c.LogMode == logModeDetail {
+		mode.SetLog(ModeDetail)
+	}
+
+	colors := NewColorScale()
+	if c.Theme != "" {
+		colors = ColorTheme(c.Theme)
+	}
+
+	var files RotateWriters
+	writers := make([]io.Writer, 0, len(c.Writers))
+	for _, name := range c.Writers {
+		switch name {
+		case writerStdout:
+			writers = append(writers, os.Stdout)
+		case writerStderr:
+			writers = append(writers, os.Stderr)
+		default:
+			f := &RotateWriter{Filename: name}
+			files = append(files, f)
+			writers = append(writers, f)
+		}
+	}
+	w := writers[0]
+	if len(writers) > 1 {
+		w = io.MultiWriter(writers...)
+	}
+
+	return HandlerOptions(
+		WithWriter(w),
+		WithLogLevel(c.Level),
+		WithVModule(rules...),
+		WithTimeFormat(timeFmt),
+		WithBuiltinSort(slices.Clone(c.Fields)),
+		WithMode(mode),
+		WithColorScale(colors),
+		WithColorful(c.Colorful),
+		WithExitCode(c.ExitCode),
+	), files, nil
+}
+
+// RotateWriters are the log files opened by Config.Build.
+type RotateWriters []*RotateWriter
+
+// Close closes all the files and returns their errors joined together.
+func (ws RotateWriters) Close() error {
+	var errs []error
+	for _, w := range ws {
+		if err := w.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReopenOnSignal reopens all the files whenever one of the given signals,
+// SIGHUP by default, is received. Calling the returned function stops it.
+func (ws RotateWriters) ReopenOnSignal(sig ...os.Signal) (stop func()) {
+	stops := make([]func(), len(ws))
+	for i, w := range ws {
+		stops[i] = w.ReopenOnSignal(sig...)
+	}
+	return func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+}
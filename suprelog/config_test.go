@@ -0,0 +1,124 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLevel(t *testing.T) {
+	for _, l := range []Level{LevelTrace, LevelDebug, LevelInfo, LevelNotice, LevelWarn, LevelError, LevelFatal, Level(3)} {
+		text, err := l.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Level
+		if err := got.UnmarshalText(text); err != nil || got != l {
+			t.Errorf("round trip of %d via %q: got %d, %v", l, text, got, err)
+		}
+	}
+	if l, err := ParseLevel(" warn "); err != nil || l != LevelWarn {
+		t.Errorf("ParseLevel(warn) = %v, %v", l, err)
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("ParseLevel(loud): want error")
+	}
+}
+
+func TestConfig_Load(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "log.json")
+	data := `{"level":"warn","time_format":"DateTime","fields":["level","time"],"typ_mode":"json","writers":["stderr"]}`
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SUPRELOG_LEVEL", "info")
+	t.Setenv("SUPRELOG_COLORFUL", "true")
+	t.Setenv("SUPRELOG_WRITERS", "stdout, "+filepath.Join(dir, "app.log"))
+
+	c := NewConfig()
+	if err := c.LoadFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c.RegisterFlags(fs, "log.")
+	if err := fs.Parse([]string{"-log.level=error", "-log.log_mode", "detail", "-log.exit_code=3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Config{
+		Level:      LevelError,
+		TimeFormat: "DateTime",
+		Fields:     []string{FieldLevel, FieldTime},
+		LogMode:    "detail",
+		TypMode:    ModeJson,
+		Colorful:   true,
+		Writers:    []string{"stdout", filepath.Join(dir, "app.log")},
+		ExitCode:   3,
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("got %+v, want %+v", c, want)
+	}
+
+	c.Writers = c.Writers[1:] // keep the test output clean
+	h, files, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.level.Get() != LevelError || h.timeFmt != time.DateTime || h.mode.log != ModeDetail || !h.isColorful || h.exitCode != 3 {
+		t.Errorf("handler does not match config: %+v", h)
+	}
+	if len(files) != 1 || files[0].Filename != filepath.Join(dir, "app.log") {
+		t.Fatalf("got files %v, want app.log", files)
+	}
+
+	NewClassic(h).Error().Msg("to file").Emit()
+	if err := files.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(files[0].Filename)
+	if err != nil || !strings.Contains(string(out), `"to file"`) {
+		t.Errorf("got %q, %v, want the record in app.log", out, err)
+	}
+}
+
+func TestConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "log.json")
+	if err := os.WriteFile(file, []byte(`{"levle":"info"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewConfig().LoadFile(file); err == nil {
+		t.Error("LoadFile with unknown field: want error")
+	}
+
+	t.Setenv("SUPRELOG_LEVEL", "loud")
+	t.Setenv("SUPRELOG_EXIT_CODE", "one")
+	err := NewConfig().LoadEnv()
+	if err == nil || !strings.Contains(err.Error(), "SUPRELOG_LEVEL") || !strings.Contains(err.Error(), "SUPRELOG_EXIT_CODE") {
+		t.Errorf("LoadEnv: got %v", err)
+	}
+
+	c := NewConfig()
+	c.Fields = []string{"time", "host"}
+	c.LogMode = "verbose"
+	c.Theme = "neon"
+	c.Writers = nil
+	_, _, err = c.Build()
+	for _, want := range []string{`unknown field "host"`, `unknown log mode "verbose"`, `unknown theme "neon"`, "no writers"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Build: got %v, want %s", err, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// A ContextExtractor returns the attributes to add to every record logged
+// with ctx, such as a request ID or a trace ID stored in the context.
+type ContextExtractor func(ctx context.Context) []slog.Attr
+
+// Attribute keys of the W3C trace context.
+const (
+	KeyTraceID = "trace_id"
+	KeySpanID  = "span_id"
+)
+
+// ContextValue returns a ContextExtractor that adds the value stored in the
+// context under key as an attribute with the given name. Nothing is added
+// if the context holds no value for key.
+func ContextValue(key any, name string) ContextExtractor {
+	return func(ctx context.Context) []slog.Attr {
+		v := ctx.Value(key)
+		if v == nil {
+			return nil
+		}
+		return []slog.Attr{slog.Any(name, v)}
+	}
+}
+
+// ContextTraceparent returns a ContextExtractor that parses the W3C
+// traceparent header value, e.g. "00-<trace-id>-<span-id>-01", stored in
+// the context under key, and adds its trace and span IDs as the trace_id
+// and span_id attributes. Nothing is added if the value is not valid.
+func ContextTraceparent(key any) ContextExtractor {
+	return func(ctx context.Context) []slog.Attr {
+		s, _ := ctx.Value(key).(string)
+		traceID, spanID, ok := parseTraceparent(s)
+		if !ok {
+			return nil
+		}
+		return []slog.Attr{slog.String(KeyTraceID, traceID), slog.String(KeySpanID, spanID)}
+	}
+}
+
+// parseTraceparent returns the trace and span IDs of a traceparent header value.
+func parseTraceparent(s string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || parts[0] == "ff" {
+		return "", "", false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || len(flags) != 2 || parts[0] == "00" && len(parts) != 4 {
+		return "", "", false
+	}
+	if !isHexID(version) || !isHexID(flags) || len(traceID) != 32 || !isHexID(traceID) || len(spanID) != 16 || !isHexID(spanID) {
+		return "", "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// isHexID reports whether s consists of lowercase hex digits only.
+func isHexID(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// contextAttrs returns the attributes the extractors of h take from ctx.
+func (h *Handler) contextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil || len(h.extractors) == 0 {
+		return nil
+	}
+	var as []slog.Attr
+	for _, fn := range h.extractors {
+		as = append(as, fn(ctx)...)
+	}
+	return as
+}
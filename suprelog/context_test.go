@@ -0,0 +1,72 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+type ctxKey string
+
+// Classic.Ctx looks values up by plain string keys.
+var tenantKey any = "tenant"
+
+func TestHandler_ContextExtractors(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithContextExtractors(
+			ContextValue(ctxKey("request_id"), "request_id"),
+			ContextTraceparent(ctxKey("traceparent")),
+			func(ctx context.Context) []slog.Attr {
+				if tenant, ok := ctx.Value(tenantKey).(string); ok {
+					return []slog.Attr{slog.String("tenant", tenant)}
+				}
+				return nil
+			},
+		),
+	)
+
+	ctx := context.WithValue(context.Background(), ctxKey("request_id"), "r-1")
+	ctx = context.WithValue(ctx, ctxKey("traceparent"), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx = context.WithValue(ctx, tenantKey, "acme")
+
+	log := NewEntry(h.WithGroup("g"))
+	log.InfoCtx(ctx, "msg", "k", "v")
+	log.Info("no context")
+	NewClassic(h).Info().Msg("classic").EmitCtx(ctx)
+
+	want := "msg | request_id=r-1 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 tenant=acme g.k=v\n" +
+		"no context\n" +
+		"classic | request_id=r-1 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 tenant=acme\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, _, ok := parseTraceparent(tt.in); ok != tt.ok {
+			t.Errorf("parseTraceparent(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+		}
+	}
+}
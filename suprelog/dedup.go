@@ -0,0 +1,307 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupOptions configures a DedupHandler.
+type DedupOptions struct {
+	Window time.Duration // Period in which identical records are collapsed, 1s if not positive
+}
+
+// DedupHandler is a slog.Handler that collapses identical records, i.e.
+// records with the same level, message and attributes, logged within a
+// window. The first record is written, the repeats are counted and
+// summarized by a copy of the record with a "repeated" attribute once
+// the window has passed. FATAL records are never collapsed.
+type DedupHandler struct {
+	handler slog.Handler
+	prefix  string // fingerprint of the attributes and groups added to the handler
+	core    *dedupCore
+}
+
+// dedupCore is the state shared by a DedupHandler and the handlers
+// derived from it with WithAttrs and WithGroup.
+type dedupCore struct {
+	window  time.Duration
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// dedupEntry tracks the repeats of one record in the current window.
+type dedupEntry struct {
+	h        slog.Handler
+	ctx      context.Context
+	r        slog.Record // the written record, with its source position resolved
+	last     time.Time   // time of the last repeat
+	start    time.Time
+	repeated int
+}
+
+// NewDedupHandler returns a DedupHandler that wraps h and starts writing
+// the summaries of expired windows. Close must be called to write the
+// pending summaries before the program exits.
+func NewDedupHandler(h slog.Handler, opts DedupOptions) *DedupHandler {
+	if h == nil {
+		panic("nil Handler")
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Second
+	}
+
+	c := &dedupCore{
+		window:  opts.Window,
+		entries: map[string]*dedupEntry{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go c.run()
+
+	return &DedupHandler{handler: h, core: c}
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (d *DedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return d.handler.Enabled(ctx, level)
+}
+
+// Handle passes r to the wrapped handler unless an identical record was
+// written within the window, in which case it is only counted.
+func (d *DedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= LevelFatal.Level() {
+		return d.handler.Handle(ctx, r)
+	}
+
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	key := d.fingerprint(r)
+
+	d.core.mu.Lock()
+	e, ok := d.core.entries[key]
+	if ok && t.Sub(e.start) < d.core.window {
+		e.repeated++
+		e.last = t
+		d.core.mu.Unlock()
+		return nil
+	}
+	// Resolve the source position now, the summary is written later
+	kept := r.Clone()
+	kept.PC = d.callerPC(r.PC)
+	d.core.entries[key] = &dedupEntry{
+		h:     d.handler,
+		ctx:   withResolvedPC(context.WithoutCancel(ctx)),
+		r:     kept,
+		start: t,
+	}
+	d.core.mu.Unlock()
+
+	if ok {
+		e.summarize()
+	}
+	return d.handler.Handle(ctx, r)
+}
+
+// callerPC resolves the source position of the wrapped handler, if it does.
+func (d *DedupHandler) callerPC(pc uintptr) uintptr {
+	if c, ok := d.handler.(callerResolver); ok {
+		return c.callerPC(pc)
+	}
+	return pc
+}
+
+// captureStack captures the stack of r for the wrapped handler, if it writes one.
+func (d *DedupHandler) captureStack(ctx context.Context, r slog.Record) context.Context {
+	if c, ok := d.handler.(stackCapturer); ok {
+		return c.captureStack(ctx, r)
+	}
+	return ctx
+}
+
+// runFatal runs the fatal hook of the wrapped handler, if it has one.
+func (d *DedupHandler) runFatal(ctx context.Context, r slog.Record) int {
+	if f, ok := d.handler.(fatalRunner); ok {
+		return f.runFatal(ctx, r)
+	}
+	return -1
+}
+
+// WithAttrs returns a DedupHandler sharing the state of d whose
+// wrapped handler has the given attributes.
+func (d *DedupHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(d.prefix)
+	for _, a := range as {
+		writeFingerprint(&b, a)
+	}
+	return &DedupHandler{handler: d.handler.WithAttrs(as), prefix: b.String(), core: d.core}
+}
+
+// WithGroup returns a DedupHandler sharing the state of d whose
+// wrapped handler has the given group.
+func (d *DedupHandler) WithGroup(name string) slog.Handler {
+	return &DedupHandler{handler: d.handler.WithGroup(name), prefix: d.prefix + name + "{", core: d.core}
+}
+
+// Close writes the summaries of all pending repeats and stops the handler.
+func (d *DedupHandler) Close() error {
+	d.core.once.Do(func() { close(d.core.stop) })
+	<-d.core.done
+	return nil
+}
+
+// fingerprint returns the key identifying records identical to r.
+func (d *DedupHandler) fingerprint(r slog.Record) string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(int(r.Level)))
+	b.WriteByte('|')
+	b.WriteString(d.prefix)
+	b.WriteByte('|')
+	b.WriteString(r.Message)
+	b.WriteByte('|')
+	r.Attrs(func(a slog.Attr) bool {
+		writeFingerprint(&b, a)
+		return true
+	})
+	return b.String()
+}
+
+func writeFingerprint(b *strings.Builder, a slog.Attr) {
+	b.WriteString(strconv.Quote(a.Key))
+	b.WriteByte('=')
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		b.WriteByte('{')
+		for _, ga := range v.Group() {
+			writeFingerprint(b, ga)
+		}
+		b.WriteByte('}')
+	} else {
+		b.WriteString(strconv.Quote(v.String()))
+	}
+	b.WriteByte(' ')
+}
+
+// run writes the summaries of expired windows until the handler is closed.
+func (c *dedupCore) run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			c.sweep(now, false)
+		case <-c.stop:
+			c.sweep(time.Time{}, true)
+			return
+		}
+	}
+}
+
+// sweep removes the entries whose window has expired as of now, or all
+// of them, and writes their summaries.
+func (c *dedupCore) sweep(now time.Time, all bool) {
+	var expired []*dedupEntry
+
+	c.mu.Lock()
+	for key, e := range c.entries {
+		if all || now.Sub(e.start) >= c.window {
+			delete(c.entries, key)
+			expired = append(expired, e)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, e := range expired {
+		e.summarize()
+	}
+}
+
+// summarize writes a copy of the record telling how often it was repeated.
+func (e *dedupEntry) summarize() {
+	if e.repeated == 0 {
+		return
+	}
+	r := slog.NewRecord(e.last, e.r.Level, e.r.Message, e.r.PC)
+	e.r.Attrs(func(a slog.Attr) bool {
+		r.AddAttrs(a)
+		return true
+	})
+	r.AddAttrs(slog.Int("repeated", e.repeated))
+	_ = e.h.Handle(e.ctx, r)
+}
+
+// onceKeys holds the keys of the messages logged through Entry.Once.
+var onceKeys sync.Map
+
+// Once returns an Entry that logs only the first message for the given key
+// over the lifetime of the process, later messages for the key are dropped.
+// A message that is not enabled at its level does not use up the key.
+func (e *Entry) Once(key string) *Entry {
+	return &Entry{&onceHandler{handler: e.handler, key: key}}
+}
+
+// onceHandler passes the first record to the wrapped handler and drops
+// all later ones with the same key.
+type onceHandler struct {
+	handler slog.Handler
+	key     string
+}
+
+func (o *onceHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if _, done := onceKeys.Load(o.key); done {
+		return false
+	}
+	return o.handler.Enabled(ctx, level)
+}
+
+func (o *onceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if _, done := onceKeys.LoadOrStore(o.key, struct{}{}); done {
+		return nil
+	}
+	return o.handler.Handle(ctx, r)
+}
+
+func (o *onceHandler) callerPC(pc uintptr) uintptr {
+	if c, ok := o.handler.(callerResolver); ok {
+		return c.callerPC(pc)
+	}
+	return pc
+}
+
+func (o *onceHandler) captureStack(ctx context.Context, r slog.Record) context.Context {
+	if c, ok := o.handler.(stackCapturer); ok {
+		return c.captureStack(ctx, r)
+	}
+	return ctx
+}
+
+func (o *onceHandler) runFatal(ctx context.Context, r slog.Record) int {
+	if f, ok := o.handler.(fatalRunner); ok {
+		return f.runFatal(ctx, r)
+	}
+	return -1
+}
+
+func (o *onceHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	return &onceHandler{handler: o.handler.WithAttrs(as), key: o.key}
+}
+
+func (o *onceHandler) WithGroup(name string) slog.Handler {
+	return &onceHandler{handler: o.handler.WithGroup(name), key: o.key}
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestDedupHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{}))
+	d := NewDedupHandler(h, DedupOptions{Window: time.Hour})
+
+	log := slog.New(d).With("svc", "api")
+	for i := 0; i < 1000; i++ {
+		log.Error("dial failed", "host", "db")
+	}
+	log.Error("dial failed", "host", "cache")
+	log.Warn("dial failed", "host", "db")
+
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "dial failed | svc=api host=db\n" +
+		"dial failed | svc=api host=cache\n" +
+		"dial failed | svc=api host=db\n" +
+		"dial failed | svc=api host=db repeated=999\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDedupHandler_Window(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{}))
+	d := NewDedupHandler(h, DedupOptions{Window: time.Hour})
+	defer d.Close()
+
+	start := time.Now()
+	for _, at := range []time.Duration{0, time.Minute, 2 * time.Minute, 61 * time.Minute} {
+		r := slog.NewRecord(start.Add(at), slog.LevelInfo, "retry", 0)
+		if err := d.Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := "retry\nretry | repeated=2\nretry\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDedupHandler_SummaryPosition(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		var buf bytes.Buffer
+		var h slog.Handler = HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{FieldPos}), WithCallerSkip(1))
+		if async {
+			h = NewAsyncHandler(h, AsyncOptions{})
+		}
+		d := NewDedupHandler(h, DedupOptions{Window: time.Hour})
+		log := NewEntry(d)
+
+		for i := 0; i < 3; i++ {
+			wrapperLog(log)
+		}
+		line := currentLine() - 2
+
+		_ = d.Close()
+		if c, ok := h.(*AsyncHandler); ok {
+			_ = c.Close()
+		}
+		want := fmt.Sprintf("suprelog/dedup_test.go:%[1]d | from wrapper\nsuprelog/dedup_test.go:%[1]d | from wrapper | repeated=2\n", line)
+		if got := buf.String(); got != want {
+			t.Errorf("async %v: got %q, want %q", async, got, want)
+		}
+	}
+}
+
+func TestEntry_Once(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewEntry(HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{}), WithLogLevel(LevelInfo)))
+
+	log.Once("test-once-debug").Debug("disabled")
+	for i := 0; i < 3; i++ {
+		log.Once("test-once-deprecated").Warnf("deprecated option %d", i)
+		log.Once("test-once-debug").Info("enabled")
+	}
+
+	if got, want := buf.String(), "deprecated option 0\nenabled\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -15,11 +15,14 @@ type defaulter interface {
 	Handler() slog.Handler
 }
 
-func defaults(def defaulter) defaulter {
+// defaults installs the handler of def as the process-wide slog
+// default, but only if the handler opted in with WithSetDefault.
+func defaults(def defaulter) {
 	handler := def.Handler()
-	logger := slog.New(handler)
-	slog.SetDefault(logger)
-	return logger
+	if h, ok := handler.(*Handler); ok && !h.setDefault {
+		return
+	}
+	slog.SetDefault(slog.New(handler))
 }
 
 var defaultHandler = NewHandler(os.Stdout)
@@ -52,7 +55,7 @@ func Dev() *Handler {
 	handler.isColorful = true
 	handler.colorScale = ColorTheme("arco")
 	handler.mode = NewMode().SetLog(ModeDetail)
-	handler.Level = LevelDebug
+	handler.level.Set(LevelDebug)
 	return handler
 }
 
@@ -60,9 +63,8 @@ func Dev() *Handler {
 // It sets up a new *Handler instance with appropriate options for production.
 func Prod() *Handler {
 	handler := NewHandler(os.Stdout)
-	handler.Level = LevelInfo
+	handler.level.Set(LevelInfo)
 	handler.onFatal = func(ctx context.Context, rec slog.Record) error {
-		// TODO: add hooks...
 		return nil
 	}
 	return handler
@@ -0,0 +1,276 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"encoding"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pokeyaro/gopkg/suprelog/internal/buffer"
+
+	"github.com/goccy/go-json"
+)
+
+// appendJSONObject writes as to buf as a JSON object, keeping the call
+// order of the attributes and nesting groups as objects.
+func appendJSONObject(buf *buffer.Buffer, as []slog.Attr) {
+	buf.WriteByte('{')
+	for i, a := range as {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		appendJSONString(buf, a.Key)
+		buf.WriteByte(':')
+		appendJSONValue(buf, a.Value)
+	}
+	buf.WriteByte('}')
+}
+
+// appendJSONValue writes v to buf as JSON, preserving its native kind.
+func appendJSONValue(buf *buffer.Buffer, v slog.Value) {
+	switch v.Kind() {
+	case slog.KindString:
+		appendJSONString(buf, v.String())
+	case slog.KindInt64:
+		*buf = strconv.AppendInt(*buf, v.Int64(), 10)
+	case slog.KindUint64:
+		*buf = strconv.AppendUint(*buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		// JSON has no representation for NaN and infinities
+		if f := v.Float64(); math.IsNaN(f) || math.IsInf(f, 0) {
+			appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, 64))
+		} else {
+			*buf = strconv.AppendFloat(*buf, f, 'g', -1, 64)
+		}
+	case slog.KindBool:
+		*buf = strconv.AppendBool(*buf, v.Bool())
+	case slog.KindDuration:
+		// Matches slog.JSONHandler: nanoseconds as an integer
+		*buf = strconv.AppendInt(*buf, int64(v.Duration()), 10)
+	case slog.KindTime:
+		appendJSONTime(buf, v.Time(), time.RFC3339Nano)
+	case slog.KindGroup:
+		appendJSONObject(buf, v.Group())
+	default:
+		appendJSONAny(buf, v.Any())
+	}
+}
+
+// appendJSONAny writes an arbitrary value to buf as JSON. Errors are
+// written as their message and values that cannot be marshaled fall
+// back to their fmt representation.
+func appendJSONAny(buf *buffer.Buffer, a any) {
+	switch x := a.(type) {
+	case error:
+		appendJSONString(buf, x.Error())
+		return
+	case json.Marshaler:
+		// Marshaled below, taking precedence over encoding.TextMarshaler
+	case encoding.TextMarshaler:
+		if data, err := x.MarshalText(); err == nil {
+			appendJSONString(buf, string(data))
+			return
+		}
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		appendJSONString(buf, fmt.Sprintf("%+v", a))
+		return
+	}
+	buf.Write(data)
+}
+
+// appendTextValue writes v to buf as its String method would.
+func appendTextValue(buf *buffer.Buffer, v slog.Value) {
+	switch v.Kind() {
+	case slog.KindString:
+		buf.WriteString(v.String())
+	case slog.KindInt64:
+		*buf = strconv.AppendInt(*buf, v.Int64(), 10)
+	case slog.KindUint64:
+		*buf = strconv.AppendUint(*buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		*buf = strconv.AppendFloat(*buf, v.Float64(), 'g', -1, 64)
+	case slog.KindBool:
+		*buf = strconv.AppendBool(*buf, v.Bool())
+	case slog.KindDuration:
+		// Duration.String is inlined, so its result stays on the stack
+		buf.WriteString(v.Duration().String())
+	case slog.KindTime:
+		// The layout of time.Time.String
+		*buf = v.Time().AppendFormat(*buf, "2006-01-02 15:04:05.999999999 -0700 MST")
+	default:
+		if err, ok := v.Any().(error); ok && v.Kind() == slog.KindAny {
+			buf.WriteString(err.Error())
+			return
+		}
+		buf.WriteString(v.String())
+	}
+}
+
+// appendJSONTime writes t to buf as a JSON string in the given layout,
+// whose output needs no escaping.
+func appendJSONTime(buf *buffer.Buffer, t time.Time, layout string) {
+	buf.WriteByte('"')
+	*buf = t.AppendFormat(*buf, layout)
+	buf.WriteByte('"')
+}
+
+// appendJSONString writes s to buf as a quoted JSON string.
+// Adapted from log/slog/json_handler.go.
+func appendJSONString(buf *buffer.Buffer, s string) {
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch b {
+			case '\\', '"':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[b>>4])
+				buf.WriteByte(hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`�`)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
+
+// appendLogfmtAttrs writes as to buf as logfmt pairs, each preceded by a
+// space. Groups are flattened into keys joined by dots.
+func appendLogfmtAttrs(buf *buffer.Buffer, prefix string, as []slog.Attr) {
+	for _, a := range as {
+		if a.Value.Kind() == slog.KindGroup {
+			appendLogfmtAttrs(buf, prefix+a.Key+".", a.Value.Group())
+			continue
+		}
+		buf.WriteByte(' ')
+		appendLogfmtString(buf, prefix+a.Key)
+		buf.WriteByte('=')
+		appendLogfmtValue(buf, a.Value)
+	}
+}
+
+// appendLogfmtString writes s to buf, quoting and escaping it if it is
+// empty or contains spaces, quotes, equal signs or control characters.
+func appendLogfmtString(buf *buffer.Buffer, s string) {
+	if needsQuoting(s) {
+		*buf = strconv.AppendQuote(*buf, s)
+	} else {
+		buf.WriteString(s)
+	}
+}
+
+// appendLogfmtValue writes the textual form of v to buf as a logfmt value.
+// Strings are quoted as they are written, other values are written in
+// place and quoted afterwards if needed.
+func appendLogfmtValue(buf *buffer.Buffer, v slog.Value) {
+	switch v.Kind() {
+	case slog.KindString:
+		appendLogfmtString(buf, v.String())
+		return
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			appendLogfmtString(buf, err.Error())
+			return
+		}
+	}
+
+	start := len(*buf)
+	switch v.Kind() {
+	case slog.KindTime:
+		*buf = v.Time().AppendFormat(*buf, time.RFC3339Nano)
+	case slog.KindAny:
+		if m, ok := v.Any().(encoding.TextMarshaler); ok {
+			if data, err := m.MarshalText(); err == nil {
+				buf.Write(data)
+				break
+			}
+		}
+		buf.WriteString(v.String())
+	default:
+		appendTextValue(buf, v)
+	}
+	quoteLogfmt(buf, start)
+}
+
+// quoteLogfmt quotes the logfmt value written to buf from start, if needed.
+// Plain ASCII values are checked in place, the others are written again.
+func quoteLogfmt(buf *buffer.Buffer, start int) {
+	value := (*buf)[start:]
+	if len(value) > 0 {
+		plain := true
+		for _, b := range value {
+			if b <= ' ' || b == '=' || b == '"' || b == '\\' || b >= 0x7f {
+				plain = false
+				break
+			}
+		}
+		if plain {
+			return
+		}
+	}
+	s := string(value)
+	*buf = (*buf)[:start]
+	appendLogfmtString(buf, s)
+}
+
+// needsQuoting reports whether s must be quoted in logfmt output.
+// Adapted from log/slog/text_handler.go.
+func needsQuoting(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b <= ' ' || b == '=' || b == '"' || b == '\\' || b == 0x7f {
+				return true
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
@@ -32,7 +32,7 @@ func ExampleDefaultLogger() {
 
 func ExampleDefaultClassical() {
 	log := suprelog.DefaultClassical()
-	log.Info().Str("hello world").Emit()
+	log.Info().Msg("hello world").Emit()
 
 	// Output:
 	// [2023-08-21 00:03:59.857] [INFO] suprelog/example_test.go:10 | hello world
@@ -54,7 +54,7 @@ func ExampleHandlerOptions() {
 		suprelog.WithAbsPath(false),
 		suprelog.WithTimeFormat(time.DateTime),
 		suprelog.WithColorful(true),
-		suprelog.WithColorScale(suprelog.ColorTheme("china")),
+		suprelog.WithColorScale(suprelog.ColorTheme("chinese")),
 		suprelog.WithMode(suprelog.NewMode().SetLog(suprelog.ModeSimplify)),
 		suprelog.WithFatalHook(func(ctx context.Context, rec slog.Record) error {
 			fmt.Println("This is a fatal callback function! You can send alarms, or dump logs to remote ELK, etc.")
@@ -240,7 +240,7 @@ func ExampleEntry_FatalCtx() {
 
 func ExampleHandler_InitClassical() {
 	logger := suprelog.HandlerOptions().InitClassical()
-	logger.Info().Str("hello world").Emit()
+	logger.Info().Msg("hello world").Emit()
 
 	// Output:
 	// [2023-08-21] | hello world
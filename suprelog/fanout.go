@@ -0,0 +1,217 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// FanoutOptions configures a FanoutHandler.
+type FanoutOptions struct {
+	// Isolate gives every sink its own queue and goroutine, so that a slow
+	// or blocked sink holds up neither the other sinks nor the caller. The
+	// records a sink cannot keep up with are dropped once its queue is full.
+	Isolate   bool
+	QueueSize int         // Capacity of the queue of each isolated sink, 1024 if not positive
+	OnError   func(error) // Optional callback for the errors of isolated sinks
+}
+
+// FanoutHandler is a slog.Handler that dispatches every record to several
+// sinks, each with its own level, format, colors and writer, e.g. colored
+// text to the console at DEBUG, JSON to a file at INFO and ERROR to stderr.
+//
+// An error or a panic in one sink does not keep the record from the other
+// sinks. The sinks are called one after another unless the handler was
+// created with FanoutOptions.Isolate, see NewFanoutHandlerWithOptions.
+type FanoutHandler struct {
+	sinks  []slog.Handler
+	queues []*AsyncHandler // the queues of isolated sinks, shared by derived handlers
+}
+
+// NewFanoutHandler returns a FanoutHandler dispatching to the given sinks
+// on the caller's goroutine.
+func NewFanoutHandler(sinks ...slog.Handler) *FanoutHandler {
+	return NewFanoutHandlerWithOptions(FanoutOptions{}, sinks...)
+}
+
+// NewFanoutHandlerWithOptions returns a FanoutHandler dispatching to the
+// given sinks. With opts.Isolate every sink is wrapped in an AsyncHandler
+// dropping the newest records when its queue is full, and Close must be
+// called to drain the queues before the program exits. FATAL records are
+// still written to all sinks synchronously, after their queues are flushed.
+func NewFanoutHandlerWithOptions(opts FanoutOptions, sinks ...slog.Handler) *FanoutHandler {
+	for _, h := range sinks {
+		if h == nil {
+			panic("nil Handler")
+		}
+	}
+	if !opts.Isolate {
+		return &FanoutHandler{sinks: sinks}
+	}
+
+	f := &FanoutHandler{sinks: make([]slog.Handler, len(sinks))}
+	for i, h := range sinks {
+		a := NewAsyncHandler(h, AsyncOptions{
+			QueueSize: opts.QueueSize,
+			Overflow:  OverflowDropNewest,
+			OnError:   opts.OnError,
+		})
+		f.sinks[i] = a
+		f.queues = append(f.queues, a)
+	}
+	return f
+}
+
+// Enabled reports whether any of the sinks handles records at the given level.
+func (f *FanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f.sinks {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes r to every sink enabled for its level and returns the
+// errors of the failing sinks joined together.
+//
+// A FATAL record is written to all sinks before the fatal hooks run
+// and the process exits with the exit code of the first sink, or 1 if
+// none of the sinks that handled it exits on fatal records.
+func (f *FanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	fatal := r.Level >= LevelFatal.Level()
+	outer := exitDeferred(ctx)
+	if fatal && !outer {
+		ctx = deferExit(ctx)
+	}
+
+	var errs []error
+	handled := false
+	for i, h := range f.sinks {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		handled = true
+		if err := handleSink(ctx, h, r.Clone()); err != nil {
+			errs = append(errs, fmt.Errorf("sink %d: %w", i, err))
+		}
+	}
+
+	if fatal && !outer && handled {
+		code := f.runFatal(ctx, r)
+		if code < 0 {
+			// The sinks are plain slog handlers, exit on their behalf
+			code = 1
+		}
+		os.Exit(code)
+	}
+	return errors.Join(errs...)
+}
+
+// handleSink calls h.Handle, turning a panic into an error.
+func handleSink(ctx context.Context, h slog.Handler, r slog.Record) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+	return h.Handle(ctx, r)
+}
+
+// captureStack captures the stack of r for the sinks that write one.
+func (f *FanoutHandler) captureStack(ctx context.Context, r slog.Record) context.Context {
+	for _, h := range f.sinks {
+		if c, ok := h.(stackCapturer); ok {
+			ctx = c.captureStack(ctx, r)
+		}
+	}
+	return ctx
+}
+
+// runFatal runs the fatal hooks of the enabled sinks and returns the exit
+// code of the first one, or -1 if none of them exits on fatal records.
+func (f *FanoutHandler) runFatal(ctx context.Context, r slog.Record) int {
+	code := -1
+	for _, h := range f.sinks {
+		fr, ok := h.(fatalRunner)
+		if !ok || !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if c := fr.runFatal(ctx, r); code < 0 {
+			code = c
+		}
+	}
+	return code
+}
+
+// WithAttrs returns a FanoutHandler whose sinks have the given attributes.
+func (f *FanoutHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	sinks := make([]slog.Handler, len(f.sinks))
+	for i, h := range f.sinks {
+		sinks[i] = h.WithAttrs(as)
+	}
+	return &FanoutHandler{sinks: sinks, queues: f.queues}
+}
+
+// WithGroup returns a FanoutHandler whose sinks have the given group.
+func (f *FanoutHandler) WithGroup(name string) slog.Handler {
+	sinks := make([]slog.Handler, len(f.sinks))
+	for i, h := range f.sinks {
+		sinks[i] = h.WithGroup(name)
+	}
+	return &FanoutHandler{sinks: sinks, queues: f.queues}
+}
+
+// Flush waits until the records queued for isolated sinks so far have
+// been handled.
+func (f *FanoutHandler) Flush() error {
+	for _, a := range f.queues {
+		_ = a.Flush()
+	}
+	return nil
+}
+
+// Close handles the records remaining in the queues of isolated sinks
+// and stops their goroutines.
+func (f *FanoutHandler) Close() error {
+	for _, a := range f.queues {
+		_ = a.Close()
+	}
+	return nil
+}
+
+// Dropped returns the number of records dropped by isolated sinks
+// because their queues were full.
+func (f *FanoutHandler) Dropped() uint64 {
+	var n uint64
+	for _, a := range f.queues {
+		n += a.Dropped()
+	}
+	return n
+}
+
+// fatalRunner is implemented by handlers that run fatal hooks and exit
+// the process on FATAL records. runFatal runs the hooks and returns the
+// exit code, or -1 if the handler does not exit.
+type fatalRunner interface {
+	runFatal(ctx context.Context, r slog.Record) int
+}
+
+// deferExitKey marks a context whose FATAL record is handled by several
+// handlers, so that they leave running the hooks and exiting to the caller.
+type deferExitKey struct{}
+
+func deferExit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deferExitKey{}, true)
+}
+
+func exitDeferred(ctx context.Context) bool {
+	deferred, _ := ctx.Value(deferExitKey{}).(bool)
+	return deferred
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fatalEnv is set for the child process started by runFatalChild.
+const fatalEnv = "SUPRELOG_FATAL_CHILD"
+
+// runFatalChild runs the calling test again in a child process with fatalEnv
+// set, so that it can exit on a FATAL record, and returns its output and
+// exit code.
+func runFatalChild(t *testing.T) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$")
+	cmd.Env = append(os.Environ(), fatalEnv+"=1")
+	out, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+type panicHandler struct{ slog.Handler }
+
+func (panicHandler) Handle(context.Context, slog.Record) error { panic("boom") }
+
+func TestFanoutHandler(t *testing.T) {
+	var console, file, stderr bytes.Buffer
+	noSort := WithBuiltinSort([]string{FieldLevel})
+
+	h := NewFanoutHandler(
+		HandlerOptions(WithWriter(&console), noSort, WithLogLevel(LevelDebug)),
+		HandlerOptions(WithWriter(&file), noSort, WithLogLevel(LevelInfo), WithMode(NewMode().SetTyp(ModeNdjson))),
+		HandlerOptions(WithWriter(&stderr), noSort, WithLogLevel(LevelError)),
+	)
+	log := slog.New(h).With("k", "v")
+
+	log.Debug("debug")
+	log.Info("info")
+	log.Error("error")
+
+	if got, want := console.String(), "[DEBUG] | debug | k=v\n[INFO] | info | k=v\n[ERROR] | error | k=v\n"; got != want {
+		t.Errorf("console: got %q, want %q", got, want)
+	}
+	if got, want := file.String(), `{"level":"INFO","msg":"info","k":"v"}`+"\n"+`{"level":"ERROR","msg":"error","k":"v"}`+"\n"; got != want {
+		t.Errorf("file: got %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), "[ERROR] | error | k=v\n"; got != want {
+		t.Errorf("stderr: got %q, want %q", got, want)
+	}
+}
+
+func TestFanoutHandler_FailingSink(t *testing.T) {
+	var buf bytes.Buffer
+	ok := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{}))
+
+	h := NewFanoutHandler(
+		HandlerOptions(WithWriter(errWriter{})),
+		panicHandler{ok},
+		ok,
+	)
+
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "hello", 0)
+	err := h.Handle(context.Background(), r)
+
+	if got, want := buf.String(), "hello\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if err == nil || !strings.Contains(err.Error(), "disk full") || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// chanWriter sends every write to its channel.
+type chanWriter chan string
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestFanoutHandler_Isolate(t *testing.T) {
+	blocked := &gateWriter{gate: make(chan struct{}), started: make(chan struct{})}
+	healthy := make(chanWriter, 3)
+	noSort := WithBuiltinSort([]string{})
+
+	h := NewFanoutHandlerWithOptions(FanoutOptions{Isolate: true},
+		HandlerOptions(WithWriter(blocked), noSort),
+		HandlerOptions(WithWriter(healthy), noSort),
+	)
+	log := slog.New(h).With("k", "v")
+
+	log.Info("1")
+	<-blocked.started
+	log.Info("2")
+	log.Info("3")
+
+	for _, want := range []string{"1 | k=v\n", "2 | k=v\n", "3 | k=v\n"} {
+		select {
+		case got := <-healthy:
+			if got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("the blocked sink held up %q", want)
+		}
+	}
+
+	close(blocked.gate)
+	_ = h.Close()
+	if got, want := blocked.String(), "1 | k=v\n2 | k=v\n3 | k=v\n"; got != want {
+		t.Errorf("blocked sink: got %q, want %q", got, want)
+	}
+}
+
+func TestFanoutHandler_FatalPlainSink(t *testing.T) {
+	if os.Getenv(fatalEnv) != "" {
+		sink := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey || a.Key == slog.LevelKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})
+		slog.New(NewFanoutHandler(sink)).Log(context.Background(), LevelFatal.Level(), "bye")
+		fmt.Println("still running")
+		return
+	}
+
+	out, code := runFatalChild(t)
+	if code != 1 || !strings.Contains(out, "msg=bye") || strings.Contains(out, "still running") {
+		t.Errorf("exit code %d, want 1, output:\n%s", code, out)
+	}
+}
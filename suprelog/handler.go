@@ -10,14 +10,16 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 
 	"github.com/pokeyaro/gopkg/suprelog/internal"
 	"github.com/pokeyaro/gopkg/suprelog/internal/buffer"
-
-	"github.com/goccy/go-json"
 )
 
 // Handler is a log handler that writes log records to an io.Writer,
@@ -32,23 +34,61 @@ type Handler struct {
 	// Flag indicating whether to use absolute or relative file paths
 	absPath bool
 
+	// Flag indicating whether to append the function name to the log position
+	funcName bool
+
+	// Policy for attributes with duplicate keys
+	dupKey DupKey
+
+	// Transformation of each attribute, including the built-in fields
+	replaceAttr func(groups []string, a slog.Attr) slog.Attr
+
+	// Redaction of sensitive values, nil if disabled
+	redactor *redactor
+
+	// Extractors of the attributes taken from the context of each record
+	extractors []ContextExtractor
+
+	// Hooks run for the records at their levels, and the handler of their errors
+	hooks       []Hook
+	onHookError func(error)
+
+	// Indicates whether stack traces are written for the records at or above the stack level
+	stackTrace bool
+	stackLevel Level
+
+	// Number of additional stack frames to skip when resolving the log position
+	callerSkip int
+
+	// Indicates whether levels are displayed by their short names, e.g. "WRN"
+	shortLevel bool
+
 	// Format string for log timestamp display
 	timeFmt string
 
 	// Indicates whether to enable colors in log output
 	isColorful bool
 
+	// Colors the output terminal can display
+	colorProfile ColorProfile
+
 	// Color scale for log level formatting
 	colorScale *ColorScale
 
 	// Mode configuration for log and type format
 	mode *Mode
 
+	// Indicates whether loggers built from the handler install it as the slog default
+	setDefault bool
+
 	// Callback function for handling fatal logs
 	onFatal func(ctx context.Context, rec slog.Record) error
 
-	// Log level for the handler
-	Level Level
+	// Log level for the handler, shared with the handlers derived from it
+	level *LevelVar
+
+	// Level overrides by source file, shared with the handlers derived from it
+	vmodule *atomic.Pointer[vmodule]
 
 	// Attributes to include in each log record
 	attrs []slog.Attr
@@ -86,45 +126,68 @@ func NewHandler(w io.Writer) *Handler {
 			FieldLevel,
 			FieldPos,
 		},
-		exitCode:   1,
-		absPath:    false,
-		timeFmt:    "2006-01-02 15:04:05.000",
-		isColorful: false,
-		colorScale: NewColorScale(),
-		mode:       NewMode().SetLog(ModeDetail),
-		onFatal:    func(ctx context.Context, rec slog.Record) error { return nil },
-		Level:      LevelDebug,
-		attrs:      []slog.Attr{},
-		groups:     []string{},
-		mu:         &sync.Mutex{},
+		exitCode:     1,
+		absPath:      false,
+		stackLevel:   LevelError,
+		timeFmt:      "2006-01-02 15:04:05.000",
+		isColorful:   false,
+		colorProfile: DetectColorProfile(w),
+		colorScale:   NewColorScale(),
+		mode:         NewMode().SetLog(ModeDetail),
+		onFatal:      func(ctx context.Context, rec slog.Record) error { return nil },
+		level:        newLevelVar(LevelDebug),
+		vmodule:      new(atomic.Pointer[vmodule]),
+		attrs:        []slog.Attr{},
+		groups:       []string{},
+		mu:           &sync.Mutex{},
 	}
 }
 
+// LevelVar returns the variable holding the log level of the handler.
+// Setting it changes the level of all handlers derived from h.
+func (h *Handler) LevelVar() *LevelVar { return h.level }
+
 // Enabled reports whether the handler handles records at the given level.
 // The handler ignores records whose level is lower.
 func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
-	minLevel := LevelInfo.Level()
-	if &h.Level != nil {
-		minLevel = h.Level.Level()
+	if level >= h.level.Level() {
+		return true
 	}
-	return level >= minLevel
+	// The override of the call site is checked in Handle
+	v := h.vmodule.Load()
+	return v != nil && level >= v.min.Level()
 }
 
-// WithAttrs returns a new BasicHandler whose attributes consists
+// WithAttrs returns a new Handler whose attributes consists
 // of h's attributes followed by attrs.
 func (h *Handler) WithAttrs(as []slog.Attr) slog.Handler {
-	if len(as) == 0 || as == nil {
+	if len(as) == 0 {
 		return h
 	}
-	h.attrs = append(h.attrs, as...)
-	return h
+	h2 := h.clone()
+	h2.attrs = append(h2.attrs, nestAttrs(h.groups, as)...)
+	return h2
 }
 
-// WithGroup adds the specified name to the list of log groups
-// associated with the Handler.
+// WithGroup returns a new Handler that qualifies all subsequent
+// attributes with the given group name.
 func (h *Handler) WithGroup(name string) slog.Handler {
-	h.groups = append(h.groups, name)
-	return h
+	if name == "" {
+		return h
+	}
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+	return h2
+}
+
+// clone returns a shallow copy of h whose attrs and groups can
+// be extended without affecting h.
+func (h *Handler) clone() *Handler {
+	h2 := *h
+	h2.attrs = slices.Clip(h.attrs)
+	h2.groups = slices.Clip(h.groups)
+	h2.hooks = slices.Clip(h.hooks)
+	return &h2
 }
 
 // Separator for prefix and content.
@@ -137,71 +200,91 @@ const ComponentSep = '|'
 // It formats the log record's timestamp, level, source location, message,
 // attributes, and any additional groups in a specified order.
 func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	// Skip the caller frames and the helper functions, unless done already
+	if !pcResolved(ctx) {
+		r.PC = h.callerPC(r.PC)
+	}
+
+	// Drop the record if it is below the level of its source file
+	if v := h.vmodule.Load(); v != nil && h.skipByVModule(v, Level(r.Level), r.PC) {
+		return nil
+	}
+
+	// Run the hooks, which may enrich or suppress the record
+	if len(h.hooks) > 0 {
+		var suppressed bool
+		if r, suppressed = h.runHooks(ctx, r); suppressed {
+			if r.Level >= LevelFatal.Level() && !exitDeferred(ctx) {
+				os.Exit(h.runFatal(ctx, r))
+			}
+			return nil
+		}
+	}
+
+	// Mask sensitive values, including the ones added by the hooks
+	if h.redactor != nil {
+		r = h.redactor.record(r)
+	}
+
 	// Create a handle state to manage formatting and output
 	state := h.newHandleState(buffer.New(), ComponentSep)
-
-	// Iterate through the user-input key-value pairs in front using slog.Record.Attrs,
-	// and store them in an external map[string]string structure.
-	fronts := make(map[string]string, r.NumAttrs())
-	iter := func(as slog.Attr) bool {
+	defer state.buf.Free()
+
+	// Collect the pre-bound attributes, the attributes extracted from the
+	// context and the record attributes, the latter qualified by the groups
+	// opened with WithGroup.
+	var attrBuf [16]slog.Attr
+	as := attrBuf[:0]
+	r.Attrs(func(a slog.Attr) bool {
 		// Detect and handle mismatched keys
-		if as.Key == badKey {
-			value := strconv.Quote(as.Value.String())
+		if a.Key == badKey {
+			value := strconv.Quote(a.Value.String())
 			message := fmt.Sprintf("Bad key error, please add the appropriate key value for %s.", value)
 			panic(message)
 		}
-		fronts[as.Key] = as.Value.String()
+		as = append(as, a)
 		return true
-	}
-	r.Attrs(iter)
-
-	// Iterate through the user-configured built-in sort order
-	for idx, item := range h.builtinSort {
-		switch item {
-		case FieldTime:
-			// Display log time
-			state.appendTime(r.Time.Format(h.timeFmt))
-		case FieldLevel:
-			// Display log level
-			level := h.Level.parse(r.Level)
-			state.appendLevel(level)
-		case FieldPos:
-			// Display log location
-			fileName, lineNumber := internal.GetSourceLocation()
+	})
 
-			// Check for invalid use of runtime package
-			if strings.Contains(fileName, "runtime") {
-				panic("Invalid handler, please use the methods of suprelog.")
-			}
+	var fronts []slog.Attr
+	if len(h.attrs) == 0 && len(h.groups) == 0 && len(h.extractors) == 0 && flatAttrs(as) {
+		// Nothing to merge, the record attributes are used as they are
+		fronts = as
+	} else {
+		fronts = h.mergeAttrs(ctx, as)
+	}
 
-			// Format the log position based on handler configuration
-			if h.absPath {
-				state.appendPosition(fileName, lineNumber)
-			} else {
-				projectRoot, err := internal.GetProjectRoot()
-				if err != nil {
-					return fmt.Errorf("Failed to get project root: %v\n", err)
-				}
-				relPath := projectRoot + strings.Split(fileName, projectRoot)[1]
-				state.appendPosition(relPath, lineNumber)
-			}
-		default:
-			// Handle unknown fields with a placeholder
-			state.buf.WriteString(badField)
-		}
+	// Let the user transform the attributes
+	if h.replaceAttr != nil {
+		fronts = h.replaceAttrs(nil, fronts)
+	}
 
-		// Add separator if not the last field
-		if idx != len(h.builtinSort)-1 {
-			state.buf.WriteByte(' ')
+	// Capture the stack trace, written below the record in text mode
+	var stack slog.Attr
+	if h.stackTrace && r.Level >= h.stackLevel.Level() {
+		if stack = h.stackAttr(ctx, r.PC); stack.Key != "" && h.mode.typ != ModeText {
+			fronts = append(fronts, stack)
 		}
 	}
 
-	// Display log message
-	state.appendString(r.Message)
+	// Collect the built-in fields and the message
+	var fieldBuf [4]builtinAttr
+	fields, err := state.builtinAttrs(fieldBuf[:0], r)
+	if err != nil {
+		return err
+	}
 
-	// Display user-defined attributes, if any
-	if r.NumAttrs() > 0 {
-		state.appendSMap(fronts)
+	// Format the record according to the type mode
+	switch h.mode.typ {
+	case ModeNdjson:
+		state.appendRecordJSON(fields, fronts)
+	case ModeLogfmt:
+		state.appendRecordLogfmt(fields, fronts)
+	default:
+		state.appendRecordText(fields, fronts)
+		if stack.Key != "" {
+			state.appendStack(stack.Value.String())
+		}
 	}
 
 	// Append newline character
@@ -212,12 +295,11 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 	defer h.mu.Unlock()
 
 	// Write formatted log record to the specified writer
-	_, err := h.w.Write(*state.buf)
+	_, err = h.w.Write(*state.buf)
 
-	// Handle fatal logs and exit
-	if r.Level == LevelFatal.Level() {
-		_ = h.onFatal(ctx, r)
-		os.Exit(h.exitCode)
+	// Handle fatal logs and exit, unless a composing handler exits on our behalf
+	if r.Level >= LevelFatal.Level() && !exitDeferred(ctx) {
+		os.Exit(h.runFatal(ctx, r))
 	}
 
 	// Return the error encountered during writing,
@@ -225,32 +307,343 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 	return err
 }
 
+// mergeAttrs merges the pre-bound attributes, the attributes extracted
+// from ctx and the record attributes as, qualified by the groups opened
+// with WithGroup.
+func (h *Handler) mergeAttrs(ctx context.Context, as []slog.Attr) []slog.Attr {
+	var merged []slog.Attr
+	for _, a := range h.attrs {
+		merged = mergeAttr(merged, h.redact(a), h.dupKey)
+	}
+	for _, a := range h.contextAttrs(ctx) {
+		merged = mergeAttr(merged, h.redact(a), h.dupKey)
+	}
+	// Cloned, as the groups keep the attributes
+	for _, a := range nestAttrs(h.groups, slices.Clone(as)) {
+		merged = mergeAttr(merged, a, h.dupKey)
+	}
+	return merged
+}
+
+// levelText returns the name or the short name of l.
+func (h *Handler) levelText(l Level) string {
+	if h.shortLevel {
+		return l.ShortString()
+	}
+	return l.String()
+}
+
+// redact returns a with its sensitive values masked, if redaction is enabled.
+func (h *Handler) redact(a slog.Attr) slog.Attr {
+	if h.redactor == nil {
+		return a
+	}
+	return h.redactor.attr(a)
+}
+
+// runFatal runs the fatal hook for r and returns the exit code.
+func (h *Handler) runFatal(ctx context.Context, r slog.Record) int {
+	_ = h.onFatal(ctx, r)
+	return h.exitCode
+}
+
+// Flush flushes the writer of the handler if it buffers output,
+// such as a bufio.Writer.
+func (h *Handler) Flush() error {
+	f, ok := h.w.(interface{ Flush() error })
+	if !ok {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return f.Flush()
+}
+
+// callerPC returns the call site pc after skipping the frames
+// of WithCallerSkip and the functions marked by Helper.
+func (h *Handler) callerPC(pc uintptr) uintptr {
+	return internal.CallerAbove(pc, h.callerSkip)
+}
+
+// appendPosition appends the formatted source location of pc
+// to dst based on the handler configuration.
+func (h *Handler) appendPosition(dst []byte, pc uintptr) ([]byte, error) {
+	fileName, lineNumber, funcName := internal.GetSourceLocation(pc)
+
+	if !h.absPath {
+		projectRoot, err := internal.GetProjectRoot()
+		if err != nil {
+			return dst, fmt.Errorf("Failed to get project root: %v\n", err)
+		}
+		fileName = relativePath(fileName, projectRoot)
+	}
+
+	dst = append(dst, fileName...)
+	dst = append(dst, ':')
+	dst = strconv.AppendInt(dst, int64(lineNumber), 10)
+	if h.funcName {
+		dst = append(dst, ' ')
+		dst = append(dst, funcName[strings.LastIndexByte(funcName, '/')+1:]...)
+	}
+	return dst, nil
+}
+
 // handleState holds state for a single call to BasicHandler.Handle.
 type handleState struct {
-	h      *Handler
-	buf    *buffer.Buffer
-	sep    byte
-	prefix *buffer.Buffer
+	h   *Handler
+	buf *buffer.Buffer
+	sep byte
 }
 
 func (h *Handler) newHandleState(buf *buffer.Buffer, sep byte) handleState {
 	s := handleState{
-		h:      h,
-		buf:    buf,
-		sep:    sep,
-		prefix: buffer.New(),
+		h:   h,
+		buf: buf,
+		sep: sep,
 	}
 	return s
 }
 
-func (s *handleState) appendTime(str string) {
+// fieldMsg identifies the message among the built-in fields.
+const fieldMsg = "msg"
+
+// builtinAttr is a built-in field of a record, or its message, as an attribute.
+type builtinAttr struct {
+	field string // FieldTime, FieldLevel, FieldPos or fieldMsg
+	attr  slog.Attr
+	pc    uintptr // Call site of a FieldPos written straight into the buffer
+}
+
+// builtinAttrs returns the built-in fields of r in the user-configured sort
+// order followed by the message, after ReplaceAttr. A zero time, an unknown
+// call site and the fields dropped by ReplaceAttr are omitted.
+func (s *handleState) builtinAttrs(fields []builtinAttr, r slog.Record) ([]builtinAttr, error) {
+	m := s.h.mode
+
+	for _, item := range s.h.builtinSort {
+		var a slog.Attr
+		switch item {
+		case FieldTime:
+			if r.Time.IsZero() {
+				continue
+			}
+			a = slog.Time(m.timeKey, r.Time)
+		case FieldLevel:
+			a = slog.Attr{Key: m.levelKey, Value: levelValue(Level(r.Level))}
+		case FieldPos:
+			if r.PC == 0 {
+				continue
+			}
+			if s.h.replaceAttr == nil {
+				// Written straight into the buffer, once the project root is known
+				if !s.h.absPath {
+					if _, err := internal.GetProjectRoot(); err != nil {
+						return nil, fmt.Errorf("Failed to get project root: %v\n", err)
+					}
+				}
+				fields = append(fields, builtinAttr{item, slog.Attr{Key: m.sourceKey}, r.PC})
+				continue
+			}
+			pos, err := s.h.appendPosition(nil, r.PC)
+			if err != nil {
+				return nil, err
+			}
+			a = slog.String(m.sourceKey, string(pos))
+		default:
+			// Unknown fields are kept as a placeholder
+			fields = append(fields, builtinAttr{item, slog.String(badField, ""), 0})
+			continue
+		}
+		fields = append(fields, builtinAttr{item, a, 0})
+	}
+	fields = append(fields, builtinAttr{fieldMsg, slog.String(m.msgKey, r.Message), 0})
+
+	if s.h.replaceAttr == nil {
+		return fields, nil
+	}
+	kept := fields[:0]
+	for _, f := range fields {
+		if f.attr.Key != badField {
+			f.attr = s.h.replaceAttr(nil, f.attr)
+			if f.attr.Key == "" {
+				continue
+			}
+		}
+		kept = append(kept, f)
+	}
+	return kept, nil
+}
+
+// builtinText returns the text of the value of a built-in field,
+// the time in the configured format and the level by its name.
+func (s *handleState) builtinText(v slog.Value) string {
+	v = v.Resolve()
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().Format(s.h.timeFmt)
+	case slog.KindAny:
+		if l, ok := v.Any().(Level); ok {
+			return s.h.levelText(l)
+		}
+	}
+	return v.String()
+}
+
+// appendRecordText writes the built-in fields in the user-configured
+// sort order, followed by the message and the attributes.
+func (s *handleState) appendRecordText(fields []builtinAttr, as []slog.Attr) {
+	written := false
+	for _, f := range fields {
+		if f.field == fieldMsg {
+			// Display log message
+			if written {
+				s.addSeparator()
+			}
+			s.appendMessage(f.attr.Key, f.attr.Value.String())
+			continue
+		}
+
+		// Add separator between fields
+		if written {
+			s.buf.WriteByte(' ')
+		}
+		written = true
+
+		switch f.field {
+		case FieldTime:
+			// Display log time
+			s.appendTime(f.attr.Value)
+		case FieldLevel:
+			// Display log level
+			s.appendLevel(s.builtinText(f.attr.Value))
+		case FieldPos:
+			// Display log location
+			if f.pc != 0 {
+				on := s.startColor(s.colors().Pos)
+				s.appendPos(f.pc)
+				s.endColor(on)
+			} else {
+				s.appendPosition(s.builtinText(f.attr.Value))
+			}
+		default:
+			// Handle unknown fields with a placeholder
+			s.buf.WriteString(badField)
+		}
+	}
+
+	// Display user-defined attributes, if any
+	if len(as) > 0 {
+		s.appendAttrs(as)
+	}
+}
+
+// appendRecordJSON writes the whole record as a single JSON object. The
+// built-in fields keep the user-configured sort order and are followed
+// by the message and the attributes.
+func (s *handleState) appendRecordJSON(fields []builtinAttr, as []slog.Attr) {
+	s.buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			s.buf.WriteByte(',')
+		}
+		appendJSONString(s.buf, f.attr.Key)
+		s.buf.WriteByte(':')
+		switch v := f.attr.Value.Resolve(); {
+		case f.pc != 0:
+			s.appendJSONPos(f.pc)
+		case f.attr.Key == badField:
+			s.buf.WriteString(`null`)
+		case v.Kind() == slog.KindTime:
+			appendJSONTime(s.buf, v.Time(), s.h.timeFmt)
+		case v.Kind() == slog.KindAny:
+			appendJSONString(s.buf, s.builtinText(v))
+		default:
+			appendJSONValue(s.buf, v)
+		}
+	}
+
+	for i, a := range as {
+		if i > 0 || len(fields) > 0 {
+			s.buf.WriteByte(',')
+		}
+		appendJSONString(s.buf, a.Key)
+		s.buf.WriteByte(':')
+		appendJSONValue(s.buf, a.Value)
+	}
+	s.buf.WriteByte('}')
+}
+
+// appendRecordLogfmt writes the whole record as strict logfmt key=value
+// pairs. The built-in fields keep the user-configured sort order and are
+// followed by the message and the attributes, groups as dotted keys.
+func (s *handleState) appendRecordLogfmt(fields []builtinAttr, as []slog.Attr) {
+	for i, f := range fields {
+		if i > 0 {
+			s.buf.WriteByte(' ')
+		}
+		if f.pc != 0 {
+			appendLogfmtString(s.buf, f.attr.Key)
+			s.buf.WriteByte('=')
+			s.appendLogfmtPos(f.pc)
+			continue
+		}
+		appendLogfmtString(s.buf, f.attr.Key)
+		s.buf.WriteByte('=')
+		if v := f.attr.Value.Resolve(); v.Kind() == slog.KindTime {
+			start := len(*s.buf)
+			*s.buf = v.Time().AppendFormat(*s.buf, s.h.timeFmt)
+			quoteLogfmt(s.buf, start)
+		} else {
+			appendLogfmtString(s.buf, s.builtinText(v))
+		}
+	}
+	appendLogfmtAttrs(s.buf, "", as)
+}
+
+func (s *handleState) appendTime(v slog.Value) {
 	s.buf.WriteByte('[')
-	s.buf.WriteString(str)
+	on := s.startColor(s.colors().Time)
+	if v = v.Resolve(); v.Kind() == slog.KindTime {
+		*s.buf = v.Time().AppendFormat(*s.buf, s.h.timeFmt)
+	} else {
+		s.buf.WriteString(s.builtinText(v))
+	}
+	s.endColor(on)
 	s.buf.WriteByte(']')
 }
 
+// appendPos writes the position of pc straight into the buffer.
+func (s *handleState) appendPos(pc uintptr) {
+	*s.buf, _ = s.h.appendPosition(*s.buf, pc)
+}
+
+// appendJSONPos writes the position of pc as a JSON string.
+func (s *handleState) appendJSONPos(pc uintptr) {
+	start := len(*s.buf)
+	s.buf.WriteByte('"')
+	s.appendPos(pc)
+	for _, b := range (*s.buf)[start+1:] {
+		if b < ' ' || b == '"' || b == '\\' || b >= utf8.RuneSelf {
+			// Write it again, escaped
+			pos := string((*s.buf)[start+1:])
+			*s.buf = (*s.buf)[:start]
+			appendJSONString(s.buf, pos)
+			return
+		}
+	}
+	s.buf.WriteByte('"')
+}
+
+// appendLogfmtPos writes the position of pc as a logfmt value.
+func (s *handleState) appendLogfmtPos(pc uintptr) {
+	start := len(*s.buf)
+	s.appendPos(pc)
+	quoteLogfmt(s.buf, start)
+}
+
 func (s *handleState) appendLevel(str string) {
-	if s.h.isColorful {
+	if s.colorful() {
 		bgLevel := s.getColoredLevel(str)
 		s.buf.WriteString(bgLevel)
 	} else {
@@ -260,10 +653,8 @@ func (s *handleState) appendLevel(str string) {
 	}
 }
 
-func (s *handleState) appendPosition(str string, line int) {
-	s.buf.WriteString(str)
-	s.buf.WriteByte(':')
-	s.buf.WritePosInt(line)
+func (s *handleState) appendPosition(str string) {
+	s.appendColored(s.colors().Pos, str)
 }
 
 func (s *handleState) addSeparator() {
@@ -272,84 +663,91 @@ func (s *handleState) addSeparator() {
 	s.buf.WriteByte(' ')
 }
 
-func (s *handleState) appendString(str string) {
-	if len(s.h.builtinSort) > 0 {
-		s.addSeparator()
-	}
-
+func (s *handleState) appendMessage(key, str string) {
 	switch s.h.mode.log {
 	case ModeSimplify:
-		s.buf.WriteString(str)
+		s.appendColored(s.colors().Message, str)
 	case ModeDetail:
-		s.buf.WriteString(strconv.Quote("msg"))
+		*s.buf = strconv.AppendQuote(*s.buf, key)
 		s.buf.WriteByte(':')
-		s.buf.WriteString(strconv.Quote(str))
+		on := s.startColor(s.colors().Message)
+		*s.buf = strconv.AppendQuote(*s.buf, str)
+		s.endColor(on)
 	default:
 		s.buf.WriteString(badMode)
 	}
 }
 
-func (s *handleState) appendSMap(ms map[string]string) {
+func (s *handleState) appendAttrs(as []slog.Attr) {
 	s.addSeparator()
 
 	switch s.h.mode.typ {
 	case ModeText:
-		s.appendKVs(ms)
+		s.appendKVs(as)
 	case ModeJson:
-		s.appendJSON(ms)
+		s.appendJSON(as)
 	default:
 		s.buf.WriteString(badMode)
 	}
 }
 
-func (s *handleState) appendKVs(ms map[string]string) {
-	fnText := func() {
-		first := true
-		for key, value := range ms {
-			if !first {
-				s.buf.WriteByte(' ')
-			} else {
-				first = false
-			}
-			s.buf.WriteString(fmt.Sprintf("%s=%s", key, value))
-		}
-	}
-
+func (s *handleState) appendKVs(as []slog.Attr) {
 	switch s.h.mode.log {
 	case ModeSimplify:
-		fnText()
+		s.appendTextAttrs("", as, true)
 	case ModeDetail:
-		s.buf.WriteString(strconv.Quote(ModeText))
+		*s.buf = strconv.AppendQuote(*s.buf, ModeText)
 		s.buf.WriteByte(':')
 		s.buf.WriteByte('"')
-		fnText()
+		s.appendTextAttrs("", as, true)
 		s.buf.WriteByte('"')
 	default:
 		s.buf.WriteString(badMode)
 	}
 }
 
-func (s *handleState) appendJSON(ms map[string]string) {
-	fnJson := func() ([]byte, error) {
-		data, err := json.Marshal(ms)
-		if err != nil {
-			return nil, fmt.Errorf("Error marshaling JSON: %v\n", err)
+// appendTextAttrs writes as as key=value pairs separated by spaces, the
+// first one without if first is set, and reports whether first is still
+// set afterwards. Nested groups are flattened into dotted keys.
+func (s *handleState) appendTextAttrs(prefix string, as []slog.Attr, first bool) bool {
+	for _, a := range as {
+		if a.Value.Kind() == slog.KindGroup {
+			first = s.appendTextAttrs(prefix+a.Key+".", a.Value.Group(), first)
+			continue
 		}
-		return data, nil
+		if !first {
+			s.buf.WriteByte(' ')
+		}
+		first = false
+
+		key := prefix + a.Key
+		s.appendColored(s.colors().Key, key)
+		s.buf.WriteByte('=')
+		on := s.startColor(s.valueColor(key, a.Value))
+		appendTextValue(s.buf, a.Value)
+		s.endColor(on)
 	}
+	return first
+}
 
+func (s *handleState) appendJSON(as []slog.Attr) {
 	switch s.h.mode.log {
 	case ModeDetail:
 		s.buf.WriteString(strconv.Quote(ModeJson))
 		s.buf.WriteByte(':')
 		fallthrough
 	case ModeSimplify:
-		if data, err := fnJson(); err != nil {
-			panic(err)
-		} else {
-			s.buf.WriteString(string(data))
-		}
+		appendJSONObject(s.buf, as)
 	default:
 		s.buf.WriteString(badMode)
 	}
 }
+
+// relativePath trims fileName to start at the project root directory.
+// Files outside the project keep their parent directory and base name.
+func relativePath(fileName, projectRoot string) string {
+	if idx := strings.Index(fileName, projectRoot); idx >= 0 {
+		return fileName[idx:]
+	}
+	return filepath.Join(filepath.Base(filepath.Dir(fileName)), filepath.Base(fileName))
+}
@@ -0,0 +1,258 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+)
+
+func TestHandler_SlogTest(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{FieldTime, FieldLevel}),
+		WithMode(NewMode().SetLog(ModeDetail).SetTyp(ModeJson)),
+		WithLogLevel(LevelInfo),
+	)
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			ms = append(ms, parseDetailJSON(t, line))
+		}
+		return ms
+	}
+
+	if err := slogtest.TestHandler(h, results); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHandler_WithAttrsIsolated(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{}))
+
+	parent := slog.New(h)
+	_ = parent.With("child", "yes").WithGroup("G")
+	parent.Info("msg", "k", "v")
+
+	if got, want := buf.String(), "msg | k=v\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandler_TextGroups(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{}))
+
+	slog.New(h).WithGroup("G").Info("msg", "k", "v")
+
+	if got, want := buf.String(), "msg | G.k=v\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// parseDetailJSON parses a line written in ModeDetail and ModeJson,
+// e.g. `[time] [INFO] | "msg":"message" | "json":{"k":"v"}`.
+func parseDetailJSON(t *testing.T, line string) map[string]any {
+	t.Helper()
+
+	m := map[string]any{}
+	parts := strings.SplitN(line, " | ", 3)
+
+	header := strings.Split(strings.Trim(parts[0], "[]"), "] [")
+	if len(header) == 2 {
+		m[slog.TimeKey] = header[0]
+	}
+	m[slog.LevelKey] = header[len(header)-1]
+
+	msg, err := strconv.Unquote(strings.TrimPrefix(parts[1], `"msg":`))
+	if err != nil {
+		t.Fatalf("%q: %v", line, err)
+	}
+	m[slog.MessageKey] = msg
+
+	if len(parts) == 3 {
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(parts[2], `"json":`)), &m); err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+	}
+	return m
+}
+
+type userValue struct{ name string }
+
+func (u userValue) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", u.name), slog.Int("age", 30))
+}
+
+func TestHandler_OrderedTypedJSON(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithMode(NewMode().SetTyp(ModeJson)),
+	)
+
+	slog.New(h).Info("msg",
+		"z", 1, "b", true, "a", 1.5, "s", "x\"y",
+		"user", userValue{"John"},
+		slog.Group("g", "d", time.Second),
+	)
+
+	want := `msg | {"z":1,"b":true,"a":1.5,"s":"x\"y","user":{"name":"John","age":30},"g":{"d":1000000000}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandler_DupKey(t *testing.T) {
+	tests := []struct {
+		dup  DupKey
+		want string
+	}{
+		{DupKeyLastWins, "msg | k=3 g.a=2 x=y\n"},
+		{DupKeyFirstWins, "msg | k=1 g.a=1 x=y\n"},
+		{DupKeyKeepAll, "msg | k=1 g.a=1 g.a=2 k=3 x=y\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		h := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{}), WithDupKey(tt.dup))
+
+		slog.New(h).With("k", 1, slog.Group("g", "a", 1)).
+			Info("msg", "k", 3, slog.Group("g", "a", 2), "x", "y")
+
+		if got := buf.String(); got != tt.want {
+			t.Errorf("DupKey(%d): got %q, want %q", tt.dup, got, tt.want)
+		}
+	}
+}
+
+func TestHandler_SlogTestNdjson(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{FieldTime, FieldLevel, FieldPos}),
+		WithMode(NewMode().SetTyp(ModeNdjson)),
+		WithLogLevel(LevelInfo),
+	)
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n")) {
+			var m map[string]any
+			if err := json.Unmarshal(line, &m); err != nil {
+				t.Fatalf("%q: %v", line, err)
+			}
+			ms = append(ms, m)
+		}
+		return ms
+	}
+
+	if err := slogtest.TestHandler(h, results); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHandler_NdjsonKeys(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{FieldLevel, FieldTime}),
+		WithTimeFormat(time.DateOnly),
+		WithMode(NewMode().SetTyp(ModeNdjson).SetKeys("@timestamp", "severity", "", "message")),
+	)
+
+	r := slog.NewRecord(time.Date(2023, 8, 21, 0, 0, 0, 0, time.UTC), slog.LevelWarn, "hi", 0)
+	r.AddAttrs(slog.Int("n", 1))
+	if err := h.WithGroup("g").Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"severity":"WARN","@timestamp":"2023-08-21","message":"hi","g":{"n":1}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandler_Logfmt(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{FieldTime, FieldLevel}),
+		WithTimeFormat(time.DateOnly),
+		WithMode(NewMode().SetTyp(ModeLogfmt)),
+	)
+
+	r := slog.NewRecord(time.Date(2023, 8, 21, 0, 0, 0, 0, time.UTC), slog.LevelInfo, `say "hi"`, 0)
+	r.AddAttrs(
+		slog.String("plain", "value"),
+		slog.String("space", "a b"),
+		slog.String("multi", "line1\nline2"),
+		slog.String("empty", ""),
+		slog.Group("g", slog.Int("n", 1), slog.String("eq", "a=b")),
+	)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `time=2023-08-21 level=INFO msg="say \"hi\"" plain=value space="a b" multi="line1\nline2" empty="" g.n=1 g.eq="a=b"` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandler_ReplaceAttr(t *testing.T) {
+	replace := func(groups []string, a slog.Attr) slog.Attr {
+		switch {
+		case len(groups) == 0 && a.Key == KeyTime:
+			return slog.String("@timestamp", a.Value.Time().UTC().Format(time.RFC3339))
+		case len(groups) == 0 && a.Key == KeyLevel:
+			return slog.String("severity", strings.ToLower(a.Value.Any().(Level).String()))
+		case len(groups) == 0 && a.Key == KeyMsg:
+			a.Key = "message"
+		case a.Key == "secret":
+			return slog.Attr{}
+		case strings.Join(groups, ".") == "s.g" && a.Key == "n":
+			a.Value = slog.IntValue(int(a.Value.Int64()) * 10)
+		}
+		return a
+	}
+
+	r := slog.NewRecord(time.Date(2023, 8, 21, 1, 2, 3, 0, time.UTC), slog.LevelWarn, "hi", 0)
+	r.AddAttrs(slog.String("secret", "x"), slog.Group("g", slog.Int("n", 1), slog.String("secret", "y")))
+
+	tests := []struct {
+		typ  string
+		want string
+	}{
+		{ModeNdjson, `{"@timestamp":"2023-08-21T01:02:03Z","severity":"warn","message":"hi","s":{"g":{"n":10}}}`},
+		{ModeLogfmt, `@timestamp=2023-08-21T01:02:03Z severity=warn message=hi s.g.n=10`},
+		{ModeText, `[2023-08-21T01:02:03Z] [warn] | hi | s.g.n=10`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		h := HandlerOptions(
+			WithWriter(&buf),
+			WithBuiltinSort([]string{FieldTime, FieldLevel}),
+			WithMode(NewMode().SetTyp(tt.typ)),
+			WithReplaceAttr(replace),
+		)
+		if err := h.WithGroup("s").Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.typ, got, tt.want)
+		}
+	}
+}
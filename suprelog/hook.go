@@ -0,0 +1,148 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+)
+
+// ErrSuppress is returned by a Hook to keep the record from being written.
+// A suppressed FATAL record still ends the process.
+var ErrSuppress = errors.New("suprelog: record suppressed by hook")
+
+// A Hook is run by a Handler for every record at one of its levels before
+// the record is written. It may inspect the record, enrich it by adding
+// attributes, or suppress it by returning ErrSuppress.
+type Hook interface {
+	// Levels returns the levels the hook runs for, all levels if empty.
+	Levels() []Level
+
+	// Fire runs the hook for r.
+	Fire(ctx context.Context, r *slog.Record) error
+}
+
+// NewHook returns a Hook that calls fn for the records at the given levels,
+// or at all levels if none are given.
+func NewHook(fn func(ctx context.Context, r *slog.Record) error, levels ...Level) Hook {
+	return &funcHook{fn: fn, levels: levels}
+}
+
+type funcHook struct {
+	fn     func(ctx context.Context, r *slog.Record) error
+	levels []Level
+}
+
+func (f *funcHook) Levels() []Level { return f.levels }
+
+func (f *funcHook) Fire(ctx context.Context, r *slog.Record) error { return f.fn(ctx, r) }
+
+// AsyncHook returns a Hook that runs hook on its own goroutine with a copy
+// of the record, so that slow hooks, e.g. ones calling remote services, do
+// not delay logging. The context passed to hook is canceled after timeout,
+// if positive, and a hook still running then is reported as failed.
+// An asynchronous hook can neither enrich nor suppress the record.
+func AsyncHook(hook Hook, timeout time.Duration) Hook {
+	return &asyncHook{hook: hook, timeout: timeout}
+}
+
+type asyncHook struct {
+	hook    Hook
+	timeout time.Duration
+}
+
+func (a *asyncHook) Levels() []Level { return a.hook.Levels() }
+
+func (a *asyncHook) Fire(ctx context.Context, r *slog.Record) error {
+	a.start(ctx, *r, nil)
+	return nil
+}
+
+// start runs the hook in the background and passes its error, if any, to report.
+func (a *asyncHook) start(ctx context.Context, r slog.Record, report func(error)) {
+	ctx = context.WithoutCancel(ctx)
+	r = r.Clone()
+
+	go func() {
+		cancel := context.CancelFunc(func() {})
+		if a.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		}
+		defer cancel()
+
+		done := make(chan error, 1)
+		go func() { done <- fireHook(ctx, a.hook, &r) }()
+
+		var err error
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			err = fmt.Errorf("timed out after %v", a.timeout)
+		}
+		if err != nil && !errors.Is(err, ErrSuppress) && report != nil {
+			report(err)
+		}
+	}()
+}
+
+// fireHook runs hook, turning a panic into an error.
+func fireHook(ctx context.Context, hook Hook, r *slog.Record) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+	return hook.Fire(ctx, r)
+}
+
+// hookFires reports whether hook runs for records at level l.
+func hookFires(hook Hook, l slog.Level) bool {
+	levels := hook.Levels()
+	return len(levels) == 0 || slices.ContainsFunc(levels, func(hl Level) bool { return hl.Level() == l })
+}
+
+// runHooks runs the hooks of h for a clone of rec in the order they were
+// added and returns it, as enriched by the hooks, and whether it was
+// suppressed. A failing hook does not keep the others from running, its
+// error is passed to the hook error handler.
+func (h *Handler) runHooks(ctx context.Context, rec slog.Record) (_ slog.Record, suppressed bool) {
+	r := rec.Clone()
+	for i, hook := range h.hooks {
+		if !hookFires(hook, r.Level) {
+			continue
+		}
+
+		i := i
+		report := func(err error) { h.hookError(fmt.Errorf("hook %d: %w", i, err)) }
+
+		if a, ok := hook.(*asyncHook); ok {
+			a.start(ctx, r, report)
+			continue
+		}
+
+		err := fireHook(ctx, hook, &r)
+		switch {
+		case errors.Is(err, ErrSuppress):
+			suppressed = true
+		case err != nil:
+			report(err)
+		}
+	}
+	return r, suppressed
+}
+
+// hookError passes err to the hook error handler, or writes it to stderr.
+func (h *Handler) hookError(err error) {
+	if h.onHookError != nil {
+		h.onHookError(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "suprelog: %v\n", err)
+}
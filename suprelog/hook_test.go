@@ -0,0 +1,123 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHandler_Hooks(t *testing.T) {
+	var (
+		buf      bytes.Buffer
+		errs     []string
+		failures atomic.Int64
+	)
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithHookErrorHandler(func(err error) { errs = append(errs, err.Error()) }),
+		WithHooks(
+			NewHook(func(ctx context.Context, r *slog.Record) error {
+				failures.Add(1)
+				return nil
+			}, LevelError),
+			NewHook(func(ctx context.Context, r *slog.Record) error {
+				panic("boom")
+			}, LevelWarn),
+			NewHook(func(ctx context.Context, r *slog.Record) error {
+				if r.Message == "noise" {
+					return ErrSuppress
+				}
+				return nil
+			}),
+		),
+	)
+	h.AddHooks(NewHook(func(ctx context.Context, r *slog.Record) error {
+		r.AddAttrs(slog.String("build", "v1.2.3"))
+		return nil
+	}))
+
+	log := slog.New(h.WithGroup("g"))
+	log.Info("noise")
+	log.Warn("careful", "k", 1)
+	log.Error("failed")
+	log.Error("failed again")
+
+	want := "careful | g.k=1 g.build=v1.2.3\n" +
+		"failed | g.build=v1.2.3\n" +
+		"failed again | g.build=v1.2.3\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if n := failures.Load(); n != 2 {
+		t.Errorf("counted %d errors, want 2", n)
+	}
+	if got, want := strings.Join(errs, ","), "hook 1: panic: boom"; got != want {
+		t.Errorf("hook errors %q, want %q", got, want)
+	}
+}
+
+func TestHandler_AsyncHook(t *testing.T) {
+	var (
+		buf  bytes.Buffer
+		mu   sync.Mutex
+		errs []error
+		seen = make(chan string, 1)
+	)
+	done := make(chan struct{}, 2)
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithHookErrorHandler(func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			done <- struct{}{}
+		}),
+		WithHooks(
+			AsyncHook(NewHook(func(ctx context.Context, r *slog.Record) error {
+				seen <- r.Message
+				return ErrSuppress
+			}), 0),
+			AsyncHook(NewHook(func(ctx context.Context, r *slog.Record) error {
+				<-ctx.Done()
+				return nil
+			}), 10*time.Millisecond),
+			AsyncHook(NewHook(func(ctx context.Context, r *slog.Record) error {
+				return errors.New("unavailable")
+			}), time.Second),
+		),
+	)
+
+	slog.New(h).Warn("forwarded")
+
+	if got := <-seen; got != "forwarded" {
+		t.Errorf("hook saw %q", got)
+	}
+	<-done
+	<-done
+
+	if got, want := buf.String(), "forwarded\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	got := strings.Join(msgs, ",")
+	if !strings.Contains(got, "hook 1: timed out after 10ms") || !strings.Contains(got, "hook 2: unavailable") {
+		t.Errorf("hook errors %q", got)
+	}
+}
@@ -8,20 +8,114 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
+	"sync/atomic"
 )
 
-// GetProjectRoot returns the root directory of the current project.
+// GetProjectRoot returns the root directory of the current project,
+// the base name of the working directory when it is first called.
 func GetProjectRoot() (string, error) {
+	return projectRoot()
+}
+
+var projectRoot = sync.OnceValues(func() (string, error) {
 	currentDir, err := os.Getwd()
 	if err != nil {
 		return "", err
 	}
 	return filepath.Base(currentDir), nil
+})
+
+// helpers holds the names of the functions marked by MarkHelper,
+// hasHelpers reports whether there are any.
+var (
+	helpers    sync.Map
+	hasHelpers atomic.Bool
+)
+
+// MarkHelper marks the function skip frames above the caller of
+// MarkHelper as a helper, so that CallerPC steps over it.
+func MarkHelper(skip int) {
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		helpers.Store(fn.Name(), struct{}{})
+		hasHelpers.Store(true)
+	}
+}
+
+// CallerPC returns the program counter of the function skip frames
+// above the caller of CallerPC, stepping over functions marked as
+// helpers. It returns 0 if the stack is not deep enough.
+func CallerPC(skip int) uintptr {
+	var pcs [32]uintptr
+	// skip [runtime.Callers, CallerPC]
+	n := runtime.Callers(skip+2, pcs[:])
+	return skipHelpers(pcs[:n])
 }
 
-// GetSourceLocation returns the file path and line number of the
-// calling function's source code location.
-func GetSourceLocation() (string, int) {
-	_, file, line, _ := runtime.Caller(5)
-	return file, line
+// CallerAbove returns the program counter of the function skip frames
+// above the call site pc on the stack of the calling goroutine, stepping
+// over functions marked as helpers. It returns pc if the call site is not
+// on the stack, e.g. when the record is handled on another goroutine,
+// and 0 if the stack is not deep enough.
+func CallerAbove(pc uintptr, skip int) uintptr {
+	if pc == 0 || (skip <= 0 && !hasHelpers.Load()) {
+		return pc
+	}
+
+	var pcs [64]uintptr
+	// skip [runtime.Callers, CallerAbove]
+	n := runtime.Callers(2, pcs[:])
+	for i := 0; i < n; i++ {
+		if pcs[i] == pc {
+			return skipHelpers(pcs[min(i+max(skip, 0), n):n])
+		}
+	}
+	return pc
+}
+
+// skipHelpers returns the first of pcs that is not a helper, or 0 if there is none.
+func skipHelpers(pcs []uintptr) uintptr {
+	if len(pcs) > 0 && !hasHelpers.Load() {
+		return pcs[0]
+	}
+	for _, pc := range pcs {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		if _, ok := helpers.Load(frame.Function); !ok {
+			return pc
+		}
+	}
+	return 0
+}
+
+// locations caches the source code locations by program counter,
+// of which there are as many as logging call sites.
+var (
+	locationsMu sync.RWMutex
+	locations   = map[uintptr]location{}
+)
+
+type location struct {
+	file string
+	line int
+	fn   string
+}
+
+// GetSourceLocation returns the file path, line number and function
+// name of the source code location of the given program counter.
+func GetSourceLocation(pc uintptr) (string, int, string) {
+	locationsMu.RLock()
+	loc, ok := locations[pc]
+	locationsMu.RUnlock()
+	if !ok {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		loc = location{frame.File, frame.Line, frame.Function}
+		locationsMu.Lock()
+		locations[pc] = loc
+		locationsMu.Unlock()
+	}
+	return loc.file, loc.line, loc.fn
 }
@@ -5,7 +5,11 @@
 package suprelog
 
 import (
+	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
 // A Level is the importance or severity of a log event.
@@ -23,26 +27,15 @@ const (
 	LevelFatal  Level = 12
 )
 
-// String returns a human-readable name for the level.
+// String returns the name of the level. A level without a name is named
+// after the next lower level with one, slog-style, e.g. "INFO+1".
 func (l Level) String() string {
-	switch l {
-	case LevelTrace:
-		return "TRACE"
-	case LevelDebug:
-		return "DEBUG"
-	case LevelInfo:
-		return "INFO"
-	case LevelNotice:
-		return "NOTICE"
-	case LevelWarn:
-		return "WARN"
-	case LevelError:
-		return "ERROR"
-	case LevelFatal:
-		return "FATAL"
-	default:
-		return "UNKNOWN"
-	}
+	return currentLevels().name(l, false)
+}
+
+// ShortString returns the short name of the level, e.g. "WRN" or "INF+1".
+func (l Level) ShortString() string {
+	return currentLevels().name(l, true)
 }
 
 // Level returns the log level as a slog.Level.
@@ -52,25 +45,87 @@ func (l Level) Level() slog.Level { return slog.Level(l) }
 // Int returns the integer representation of the log level.
 func (l Level) Int() int { return int(l) }
 
-func (l Level) parse(level slog.Level) string {
-	switch int(level) {
-	case LevelTrace.Int():
-		return "TRACE"
-	case LevelDebug.Int():
-		return "DEBUG"
-	case LevelInfo.Int():
-		return "INFO"
-	case LevelNotice.Int():
-		return "NOTICE"
-	case LevelWarn.Int():
-		return "WARN"
-	case LevelError.Int():
-		return "ERROR"
-	case LevelFatal.Int():
-		return "FATAL"
-	default:
-		return "UNKNOWN"
+// ParseLevel returns the level with the given name or short name,
+// case-insensitively, e.g. "debug", "WRN" or "INFO+1", or the level with
+// the given integer value.
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	t := currentLevels()
+	if l, ok := t.byName[name]; ok {
+		return l, nil
+	}
+	if n, err := strconv.Atoi(name); err == nil {
+		return Level(n), nil
+	}
+	if i := strings.LastIndexAny(name, "+-"); i > 0 {
+		base, ok := t.byName[name[:i]]
+		off, err := strconv.Atoi(name[i:])
+		if ok && err == nil {
+			return base + Level(off), nil
+		}
+	}
+	return 0, fmt.Errorf("suprelog: unknown level %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler by returning the level name.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler by parsing the text with ParseLevel.
+func (l *Level) UnmarshalText(data []byte) error {
+	v, err := ParseLevel(string(data))
+	if err != nil {
+		return err
+	}
+	*l = v
+	return nil
+}
+
+// Set implements flag.Value by parsing the level with ParseLevel.
+func (l *Level) Set(s string) error { return l.UnmarshalText([]byte(s)) }
+
+// A LevelVar is a Level variable, to allow a Handler level to change
+// dynamically while other goroutines are logging. The zero LevelVar
+// corresponds to LevelInfo.
+type LevelVar struct {
+	val atomic.Int64
+}
+
+func newLevelVar(l Level) *LevelVar {
+	v := new(LevelVar)
+	v.Set(l)
+	return v
+}
+
+// Get returns the current level.
+func (v *LevelVar) Get() Level { return Level(v.val.Load()) }
+
+// Set sets the level.
+func (v *LevelVar) Set(l Level) { v.val.Store(int64(l)) }
+
+// Level returns the current level as a slog.Level.
+// It implements the Leveler interface.
+func (v *LevelVar) Level() slog.Level { return v.Get().Level() }
+
+// String returns the name of the current level, e.g. "LevelVar(INFO)".
+func (v *LevelVar) String() string { return fmt.Sprintf("LevelVar(%s)", v.Get()) }
+
+// levelValues holds the levels around the built-in ones as slog values,
+// so that logging at them does not allocate.
+var levelValues = func() (vs [32]slog.Value) {
+	for i := range vs {
+		vs[i] = slog.AnyValue(Level(i - 16))
+	}
+	return vs
+}()
+
+// levelValue returns l as a slog value.
+func levelValue(l Level) slog.Value {
+	if l >= -16 && l < 16 {
+		return levelValues[l+16]
 	}
+	return slog.AnyValue(l)
 }
 
 //var slogLevel = []slog.Level{
@@ -5,7 +5,12 @@
 package suprelog
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"log/slog"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -28,3 +33,97 @@ func TestLevel_Level(t *testing.T) {
 	fmt.Println(LevelError.Level())
 	fmt.Println(LevelFatal.Level()) // ERROR+4
 }
+
+func TestLevel_Names(t *testing.T) {
+	tests := []struct {
+		l           Level
+		name, short string
+	}{
+		{LevelWarn, "WARN", "WRN"},
+		{LevelInfo + 1, "INFO+1", "INF+1"},
+		{LevelTrace - 2, "TRACE-2", "TRC-2"},
+		{LevelFatal + 4, "FATAL+4", "FTL+4"},
+	}
+	for _, tt := range tests {
+		if got := tt.l.String(); got != tt.name {
+			t.Errorf("Level(%d).String() = %q, want %q", tt.l, got, tt.name)
+		}
+		if got := tt.l.ShortString(); got != tt.short {
+			t.Errorf("Level(%d).ShortString() = %q, want %q", tt.l, got, tt.short)
+		}
+		for _, s := range []string{tt.name, strings.ToLower(tt.short)} {
+			if l, err := ParseLevel(s); err != nil || l != tt.l {
+				t.Errorf("ParseLevel(%q) = %d, %v, want %d", s, l, err, tt.l)
+			}
+		}
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	const LevelAudit = Level(10)
+	defer func(t *levelTable) { levelsTab.Store(t) }(currentLevels())
+
+	if err := RegisterLevel(LevelSpec{Level: LevelAudit, Name: "audit", Short: "AUD", Color: "#8E44AD"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, spec := range []LevelSpec{
+		{Level: 11, Name: "WARN"},
+		{Level: 11, Name: "LOUD", Short: "AUD"},
+		{Level: 11, Name: "A-B"},
+		{Level: 11, Name: "42"},
+		{Level: 11, Name: "LOUD", Color: "purple"},
+	} {
+		if err := RegisterLevel(spec); err == nil {
+			t.Errorf("RegisterLevel(%+v): want error", spec)
+		}
+	}
+
+	if got := (LevelAudit + 1).String(); got != "AUDIT+1" {
+		t.Errorf("got %q", got)
+	}
+
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{FieldLevel}),
+		WithLogLevel(LevelError),
+		WithColorful(true),
+		WithColorProfile(Color256),
+		WithColorScale(ColorTheme("arco")),
+	)
+	c := NewClassic(h)
+	c.Level(LevelAudit).Msgf("user %s deleted", "bob").Emit()
+	c.Level(LevelError).Msg("failed").Emit()
+	c.Level(LevelWarn).Msg("hidden").Emit()
+
+	want := Color256.sgr(0x8E, 0x44, 0xAD, 48) + "\033[97m[AUDIT]\033[0m | user bob deleted\n" +
+		rgbToAnsi(Color256, ColorTheme("arco").Colors[5].RGB...) + "\033[30m[ERROR]\033[0m | failed\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	h2 := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{FieldLevel}), WithShortLevel(true))
+	slog.New(h2).Log(context.Background(), LevelAudit.Level(), "short")
+	if got, want := buf.String(), "[AUD] | short\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRegisterLevel_AboveFatal(t *testing.T) {
+	if os.Getenv(fatalEnv) != "" {
+		const LevelPanic = LevelFatal + 4
+		if err := RegisterLevel(LevelSpec{Level: LevelPanic, Name: "PANIC"}); err != nil {
+			t.Fatal(err)
+		}
+		h := HandlerOptions(WithWriter(os.Stdout), WithBuiltinSort([]string{FieldLevel}), WithExitCode(5))
+		NewClassic(h).Level(LevelPanic).Msg("bye").Emit()
+		fmt.Println("still running")
+		return
+	}
+
+	out, code := runFatalChild(t)
+	if code != 5 || !strings.Contains(out, "[PANIC] | bye") || strings.Contains(out, "still running") {
+		t.Errorf("exit code %d, want 5, output:\n%s", code, out)
+	}
+}
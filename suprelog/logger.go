@@ -54,107 +54,107 @@ func NewEntry(h slog.Handler) *Entry {
 	return &Entry{h}
 }
 
-// Trace logs a trace message using the default logger.
+// Trace logs a trace message using the entry's handler.
 func (e *Entry) Trace(msg string, args ...any) {
-	slog.Log(context.Background(), LevelTrace.Level(), msg, args...)
+	emit(context.Background(), e.handler, LevelTrace, msg, args...)
 }
 
-// Tracef logs a formatted trace message using the default logger.
+// Tracef logs a formatted trace message using the entry's handler.
 func (e *Entry) Tracef(format string, args ...any) {
-	slog.Log(context.Background(), LevelTrace.Level(), fmt.Sprintf(format, args...))
+	emit(context.Background(), e.handler, LevelTrace, fmt.Sprintf(format, args...))
 }
 
-// TraceCtx logs a trace message using the provided context and default logger.
+// TraceCtx logs a trace message using the provided context and the entry's handler.
 func (e *Entry) TraceCtx(ctx context.Context, msg string, args ...any) {
-	slog.Log(ctx, LevelTrace.Level(), msg, args...)
+	emit(ctx, e.handler, LevelTrace, msg, args...)
 }
 
-// Debug logs a debug message using the default logger.
+// Debug logs a debug message using the entry's handler.
 func (e *Entry) Debug(msg string, args ...any) {
-	slog.Log(context.Background(), LevelDebug.Level(), msg, args...)
+	emit(context.Background(), e.handler, LevelDebug, msg, args...)
 }
 
-// Debugf logs a formatted debug message using the default logger.
+// Debugf logs a formatted debug message using the entry's handler.
 func (e *Entry) Debugf(format string, args ...any) {
-	slog.Log(context.Background(), LevelDebug.Level(), fmt.Sprintf(format, args...))
+	emit(context.Background(), e.handler, LevelDebug, fmt.Sprintf(format, args...))
 }
 
-// DebugCtx logs a debug message using the provided context and default logger.
+// DebugCtx logs a debug message using the provided context and the entry's handler.
 func (e *Entry) DebugCtx(ctx context.Context, msg string, args ...any) {
-	slog.Log(ctx, LevelDebug.Level(), msg, args...)
+	emit(ctx, e.handler, LevelDebug, msg, args...)
 }
 
-// Info logs an informational message using the default logger.
+// Info logs an informational message using the entry's handler.
 func (e *Entry) Info(msg string, args ...any) {
-	slog.Log(context.Background(), LevelInfo.Level(), msg, args...)
+	emit(context.Background(), e.handler, LevelInfo, msg, args...)
 }
 
-// Infof logs a formatted informational message using the default logger.
+// Infof logs a formatted informational message using the entry's handler.
 func (e *Entry) Infof(format string, args ...any) {
-	slog.Log(context.Background(), LevelInfo.Level(), fmt.Sprintf(format, args...))
+	emit(context.Background(), e.handler, LevelInfo, fmt.Sprintf(format, args...))
 }
 
-// InfoCtx logs an informational message using the provided context and default logger.
+// InfoCtx logs an informational message using the provided context and the entry's handler.
 func (e *Entry) InfoCtx(ctx context.Context, msg string, args ...any) {
-	slog.Log(ctx, LevelInfo.Level(), msg, args...)
+	emit(ctx, e.handler, LevelInfo, msg, args...)
 }
 
-// Notice logs a notice message using the default logger.
+// Notice logs a notice message using the entry's handler.
 func (e *Entry) Notice(msg string, args ...any) {
-	slog.Log(context.Background(), LevelNotice.Level(), msg, args...)
+	emit(context.Background(), e.handler, LevelNotice, msg, args...)
 }
 
-// Noticef logs a formatted notice message using the default logger.
+// Noticef logs a formatted notice message using the entry's handler.
 func (e *Entry) Noticef(format string, args ...any) {
-	slog.Log(context.Background(), LevelNotice.Level(), fmt.Sprintf(format, args...))
+	emit(context.Background(), e.handler, LevelNotice, fmt.Sprintf(format, args...))
 }
 
-// NoticeCtx logs a notice message using the provided context and default logger.
+// NoticeCtx logs a notice message using the provided context and the entry's handler.
 func (e *Entry) NoticeCtx(ctx context.Context, msg string, args ...any) {
-	slog.Log(ctx, LevelNotice.Level(), msg, args...)
+	emit(ctx, e.handler, LevelNotice, msg, args...)
 }
 
-// Warn logs a warning message using the default logger.
+// Warn logs a warning message using the entry's handler.
 func (e *Entry) Warn(msg string, args ...any) {
-	slog.Log(context.Background(), LevelWarn.Level(), msg, args...)
+	emit(context.Background(), e.handler, LevelWarn, msg, args...)
 }
 
-// Warnf logs a formatted warning message using the default logger.
+// Warnf logs a formatted warning message using the entry's handler.
 func (e *Entry) Warnf(format string, args ...any) {
-	slog.Log(context.Background(), LevelWarn.Level(), fmt.Sprintf(format, args...))
+	emit(context.Background(), e.handler, LevelWarn, fmt.Sprintf(format, args...))
 }
 
-// WarnCtx logs a warning message using the provided context and default logger.
+// WarnCtx logs a warning message using the provided context and the entry's handler.
 func (e *Entry) WarnCtx(ctx context.Context, msg string, args ...any) {
-	slog.Log(ctx, LevelWarn.Level(), msg, args...)
+	emit(ctx, e.handler, LevelWarn, msg, args...)
 }
 
-// Error logs an error message using the default logger.
+// Error logs an error message using the entry's handler.
 func (e *Entry) Error(msg string, args ...any) {
-	slog.Log(context.Background(), LevelError.Level(), msg, args...)
+	emit(context.Background(), e.handler, LevelError, msg, args...)
 }
 
-// Errorf logs a formatted error message using the default logger.
+// Errorf logs a formatted error message using the entry's handler.
 func (e *Entry) Errorf(format string, args ...any) {
-	slog.Log(context.Background(), LevelError.Level(), fmt.Sprintf(format, args...))
+	emit(context.Background(), e.handler, LevelError, fmt.Sprintf(format, args...))
 }
 
-// ErrorCtx logs a error message using the provided context and default logger.
+// ErrorCtx logs a error message using the provided context and the entry's handler.
 func (e *Entry) ErrorCtx(ctx context.Context, msg string, args ...any) {
-	slog.Log(ctx, LevelError.Level(), msg, args...)
+	emit(ctx, e.handler, LevelError, msg, args...)
 }
 
-// Fatal logs a fatal error message using the default logger.
+// Fatal logs a fatal error message using the entry's handler.
 func (e *Entry) Fatal(msg string, args ...any) {
-	slog.Log(context.Background(), LevelFatal.Level(), msg, args...)
+	emit(context.Background(), e.handler, LevelFatal, msg, args...)
 }
 
-// Fatalf logs a formatted fatal message using the default logger.
+// Fatalf logs a formatted fatal message using the entry's handler.
 func (e *Entry) Fatalf(format string, args ...any) {
-	slog.Log(context.Background(), LevelFatal.Level(), fmt.Sprintf(format, args...))
+	emit(context.Background(), e.handler, LevelFatal, fmt.Sprintf(format, args...))
 }
 
-// FatalCtx logs a fatal message using the provided context and default logger.
+// FatalCtx logs a fatal message using the provided context and the entry's handler.
 func (e *Entry) FatalCtx(ctx context.Context, msg string, args ...any) {
-	slog.Log(ctx, LevelFatal.Level(), msg, args...)
+	emit(ctx, e.handler, LevelFatal, msg, args...)
 }
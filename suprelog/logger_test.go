@@ -0,0 +1,122 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestEntry_OwnHandler(t *testing.T) {
+	var debugBuf, warnBuf bytes.Buffer
+	sorts := WithBuiltinSort([]string{})
+	debug := HandlerOptions(WithWriter(&debugBuf), sorts, WithLogLevel(LevelDebug)).InitLogger()
+	warn := HandlerOptions(WithWriter(&warnBuf), sorts, WithLogLevel(LevelWarn)).InitLogger()
+
+	debug.Info("to debug")
+	warn.Info("dropped")
+	warn.Warn("to warn")
+
+	if got, want := debugBuf.String(), "to debug\n"; got != want {
+		t.Errorf("debug logger: got %q, want %q", got, want)
+	}
+	if got, want := warnBuf.String(), "to warn\n"; got != want {
+		t.Errorf("warn logger: got %q, want %q", got, want)
+	}
+}
+
+func TestClassic_OwnHandler(t *testing.T) {
+	var buf bytes.Buffer
+	log := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{})).InitClassical()
+
+	log.Info().Msg("hello").Int("n", 1).Emit()
+	log.Debug().Msgf("hi %s", "~").Emit()
+
+	if got, want := buf.String(), "hello | n=1\nhi ~\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestClassic_Fields(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithMode(NewMode().SetTyp(ModeNdjson)),
+	)
+	at := time.Date(2023, 8, 21, 0, 3, 59, 0, time.UTC)
+
+	NewClassic(h).Warn().
+		Str("user", "bob").
+		Strs("roles", []string{"admin", "dev"}).
+		Int("n", 1).
+		Int64("id", 1<<40).
+		Float64("ratio", 0.5).
+		Bool("ok", true).
+		Dur("took", 1500*time.Millisecond).
+		Time("at", at).
+		Err(errors.New("boom")).
+		Err(nil).
+		Any("tags", map[string]int{"a": 1}).
+		Dict("req", NewDict().Str("method", "GET").Dict("url", NewDict().Str("path", "/"))).
+		Msg("done").
+		Emit()
+
+	want := `{"msg":"done","user":"bob","roles":["admin","dev"],"n":1,"id":1099511627776,` +
+		`"ratio":0.5,"ok":true,"took":1500000000,"at":"2023-08-21T00:03:59Z","error":"boom",` +
+		`"tags":{"a":1},"req":{"method":"GET","url":{"path":"/"}}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestClassic_Events(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewClassic(HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithLogLevel(LevelInfo),
+		WithMode(NewMode().SetTyp(ModeNdjson)),
+	))
+
+	if e := logger.Debug(); e != (*Classic)(nil) {
+		t.Fatalf("Debug() = %v, want a nil event below the handler level", e)
+	}
+	logger.Debug().Str("user", "bob").Msg("hidden").Emit()
+	if buf.Len() != 0 {
+		t.Fatalf("disabled level was written: %s", buf.String())
+	}
+
+	// Fields of an emitted event must not leak into the next one.
+	logger.Info().Str("user", "bob").Msg("first").Emit()
+	logger.Info().Msg("second").Emit()
+	logger.Str("user", "amy").Emit()
+
+	want := `{"msg":"first","user":"bob"}` + "\n" +
+		`{"msg":"second"}` + "\n" +
+		`{"msg":"","user":"amy"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestHandler_SetDefault(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+
+	HandlerOptions(WithWriter(&bytes.Buffer{})).InitLogger()
+	if slog.Default() != prev {
+		t.Fatal("InitLogger replaced the slog default without WithSetDefault")
+	}
+
+	h := HandlerOptions(WithWriter(&bytes.Buffer{}), WithSetDefault(true))
+	h.InitLogger()
+	if slog.Default().Handler() != h {
+		t.Fatal("InitLogger did not install the slog default with WithSetDefault")
+	}
+}
@@ -7,7 +7,13 @@ package suprelog
 // Mode represents the configuration options for log and type modes.
 type Mode struct {
 	log int    // Log mode: 0 for simplified mode, 1 for detailed mode
-	typ string // Type mode: "text" for text mode, "json" for JSON mode
+	typ string // Type mode: "text", "json", "ndjson" or "logfmt"
+
+	// Key names of the built-in fields and the message in ndjson and logfmt modes
+	timeKey   string
+	levelKey  string
+	sourceKey string
+	msgKey    string
 }
 
 // Mode constants for log modes.
@@ -18,15 +24,29 @@ const (
 
 // Mode constants for type modes.
 const (
-	ModeText = "text"
-	ModeJson = "json"
+	ModeText   = "text"
+	ModeJson   = "json"
+	ModeNdjson = "ndjson" // the whole record as one JSON object per line
+	ModeLogfmt = "logfmt" // the whole record as logfmt key=value pairs
+)
+
+// Default key names of the built-in fields and the message in ndjson and logfmt modes.
+const (
+	KeyTime   = "time"
+	KeyLevel  = "level"
+	KeySource = "source"
+	KeyMsg    = "msg"
 )
 
 // NewMode creates a new instance of Mode with default configuration.
 func NewMode() *Mode {
 	return &Mode{
-		log: ModeSimplify,
-		typ: ModeText,
+		log:       ModeSimplify,
+		typ:       ModeText,
+		timeKey:   KeyTime,
+		levelKey:  KeyLevel,
+		sourceKey: KeySource,
+		msgKey:    KeyMsg,
 	}
 }
 
@@ -39,3 +59,22 @@ func (m *Mode) SetTyp(typ string) *Mode {
 	m.typ = typ
 	return m
 }
+
+// SetKeys sets the key names of the time, level, source and message
+// fields used in ndjson and logfmt modes. Empty names keep the current key.
+func (m *Mode) SetKeys(time, level, source, msg string) *Mode {
+	for _, kv := range []struct {
+		dst *string
+		key string
+	}{
+		{&m.timeKey, time},
+		{&m.levelKey, level},
+		{&m.sourceKey, source},
+		{&m.msgKey, msg},
+	} {
+		if kv.key != "" {
+			*kv.dst = kv.key
+		}
+	}
+	return m
+}
@@ -10,6 +10,7 @@ import (
 	"log/slog"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -37,12 +38,14 @@ func HandlerOptions(funcs ...HandlerFunc) *Handler {
 		builtinSort: []string{FieldTime},
 		exitCode:    1,
 		absPath:     false,
+		stackLevel:  LevelError,
 		timeFmt:     time.DateOnly,
 		isColorful:  false,
 		colorScale:  nil,
 		mode:        NewMode(),
 		onFatal:     func(ctx context.Context, rec slog.Record) error { return nil },
-		Level:       LevelDebug,
+		level:       newLevelVar(LevelDebug),
+		vmodule:     new(atomic.Pointer[vmodule]),
 		w:           os.Stdout,
 		attrs:       []slog.Attr{},
 		groups:      []string{},
@@ -51,6 +54,10 @@ func HandlerOptions(funcs ...HandlerFunc) *Handler {
 
 	Option(HandlerChain(funcs)).apply(h)
 
+	if h.colorProfile == ColorAuto {
+		h.colorProfile = DetectColorProfile(h.w)
+	}
+
 	return h
 }
 
@@ -71,7 +78,24 @@ func WithBuiltinSort(sorts []string) HandlerFunc {
 // WithLogLevel configures a Handler to use the provided log level.
 func WithLogLevel(l Level) HandlerFunc {
 	return func(h *Handler) {
-		h.Level = l
+		h.level.Set(l)
+	}
+}
+
+// WithLevelVar configures a Handler to take its log level from v, so that
+// the level of several handlers can be changed at once.
+func WithLevelVar(v *LevelVar) HandlerFunc {
+	return func(h *Handler) {
+		h.level = v
+	}
+}
+
+// WithVModule configures a Handler to override its log level for the
+// source files matching the patterns of the given rules. The first
+// matching rule applies.
+func WithVModule(rules ...VModuleRule) HandlerFunc {
+	return func(h *Handler) {
+		h.vmodule.Store(newVModule(rules))
 	}
 }
 
@@ -90,6 +114,47 @@ func WithAbsPath(isAbs bool) HandlerFunc {
 	}
 }
 
+// WithFuncName configures a Handler to append the function name to the log position.
+func WithFuncName(enable bool) HandlerFunc {
+	return func(h *Handler) {
+		h.funcName = enable
+	}
+}
+
+// WithStackTrace configures a Handler to write the stack trace of the records
+// at or above its stack level, ERROR by default, under KeyStack in the JSON
+// and logfmt modes and as an indented block below the record in text mode.
+func WithStackTrace(enable bool) HandlerFunc {
+	return func(h *Handler) {
+		h.stackTrace = enable
+	}
+}
+
+// WithStackLevel configures a Handler to write stack traces for the records
+// at or above the given level, if enabled by WithStackTrace.
+func WithStackLevel(l Level) HandlerFunc {
+	return func(h *Handler) {
+		h.stackLevel = l
+	}
+}
+
+// WithCallerSkip configures a Handler to skip the given number of additional
+// stack frames when resolving the log position, e.g. for logging wrappers.
+// The frames are skipped from the call site of the record, whether it was
+// logged with an Entry, a Classic or a slog.Logger, see Helper.
+func WithCallerSkip(skip int) HandlerFunc {
+	return func(h *Handler) {
+		h.callerSkip = skip
+	}
+}
+
+// WithShortLevel configures a Handler to display levels by their short names, e.g. "WRN".
+func WithShortLevel(enable bool) HandlerFunc {
+	return func(h *Handler) {
+		h.shortLevel = enable
+	}
+}
+
 // WithTimeFormat configures a Handler to use the specified time format.
 func WithTimeFormat(timeFmt string) HandlerFunc {
 	return func(h *Handler) {
@@ -104,6 +169,14 @@ func WithColorful(isColorful bool) HandlerFunc {
 	}
 }
 
+// WithColorProfile configures a Handler to use the colors of the given
+// profile instead of detecting them from the writer and the environment.
+func WithColorProfile(p ColorProfile) HandlerFunc {
+	return func(h *Handler) {
+		h.colorProfile = p
+	}
+}
+
 // WithColorScale configures a Handler to use the specified color scale.
 func WithColorScale(colors *ColorScale) HandlerFunc {
 	return func(h *Handler) {
@@ -118,6 +191,62 @@ func WithMode(mode *Mode) HandlerFunc {
 	}
 }
 
+// WithDupKey configures a Handler to use the specified duplicate key policy.
+func WithDupKey(dup DupKey) HandlerFunc {
+	return func(h *Handler) {
+		h.dupKey = dup
+	}
+}
+
+// WithReplaceAttr configures a Handler to call fn on every attribute before
+// it is written, including the built-in time, level, source and message
+// fields, which are passed with no groups and the keys of the mode, e.g.
+// "time", "level", "source" and "msg". The time has a time.Time value and
+// the level a Level value. fn can rename, reformat or drop an attribute,
+// the latter by returning one with an empty key.
+func WithReplaceAttr(fn func(groups []string, a slog.Attr) slog.Attr) HandlerFunc {
+	return func(h *Handler) {
+		h.replaceAttr = fn
+	}
+}
+
+// WithRedaction configures a Handler to mask sensitive values in messages
+// and attributes before records are written, after the hooks have run,
+// so that the attributes added by hooks are masked as well. It panics if
+// the options are not valid, see RedactOptions.Validate.
+func WithRedaction(opts RedactOptions) HandlerFunc {
+	return func(h *Handler) {
+		rd, err := newRedactor(opts)
+		if err != nil {
+			panic(err)
+		}
+		h.redactor = rd
+	}
+}
+
+// WithContextExtractors configures a Handler to add the attributes returned
+// by the given extractors to every record, taken from the context it is logged with.
+func WithContextExtractors(fns ...ContextExtractor) HandlerFunc {
+	return func(h *Handler) {
+		h.extractors = append(h.extractors, fns...)
+	}
+}
+
+// WithHooks configures a Handler to run the given hooks for the records at their levels.
+func WithHooks(hooks ...Hook) HandlerFunc {
+	return func(h *Handler) {
+		h.hooks = append(h.hooks, hooks...)
+	}
+}
+
+// WithHookErrorHandler configures a Handler to pass the errors of failing
+// hooks to fn instead of writing them to stderr.
+func WithHookErrorHandler(fn func(error)) HandlerFunc {
+	return func(h *Handler) {
+		h.onHookError = fn
+	}
+}
+
 // WithFatalHook configures a Handler with a hook for handling fatal log records.
 func WithFatalHook(hook func(ctx context.Context, rec slog.Record) error) HandlerFunc {
 	return func(h *Handler) {
@@ -125,7 +254,16 @@ func WithFatalHook(hook func(ctx context.Context, rec slog.Record) error) Handle
 	}
 }
 
+// WithSetDefault configures a Handler to install itself as the process-wide
+// slog default when a logger is initialized from it.
+func WithSetDefault(enable bool) HandlerFunc {
+	return func(h *Handler) {
+		h.setDefault = enable
+	}
+}
+
 // InitLogger initializes a logger with the current Handler configuration.
+// The slog default is only replaced if the handler was configured with WithSetDefault.
 func (h *Handler) InitLogger() Logger {
 	handle := NewEntry(h)
 	defaults(handle)
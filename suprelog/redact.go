@@ -0,0 +1,257 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// RedactMode decides how a Handler masks a sensitive value.
+type RedactMode int
+
+// Redaction modes.
+const (
+	RedactReplace RedactMode = iota // replace the value with ***
+	RedactPartial                   // keep the last 4 characters of values longer than 8, e.g. ****1234
+	RedactHMAC                      // replace the value with a keyed HMAC, so that equal values stay correlatable
+)
+
+// Mask written in place of a redacted value.
+const redactMask = "***"
+
+// DefaultRedactKeys are the attribute keys whose values are redacted
+// if RedactOptions.Keys is nil.
+var DefaultRedactKeys = []string{
+	"password", "passwd", "pwd", "secret", "client_secret",
+	"token", "access_token", "refresh_token", "id_token",
+	"api_key", "apikey", "private_key",
+	"authorization", "cookie", "set-cookie",
+}
+
+// cardPattern matches the candidates for credit card numbers, which are
+// only redacted if they pass the Luhn check, unlike timestamps or IDs.
+var cardPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+
+// DefaultRedactPatterns match the credit card numbers, email addresses and
+// bearer tokens redacted if RedactOptions.Patterns is nil.
+var DefaultRedactPatterns = []*regexp.Regexp{
+	cardPattern,
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+}
+
+// RedactOptions configures the redaction of a Handler.
+type RedactOptions struct {
+	// Keys are the attribute keys, matched case-insensitively, whose values
+	// are redacted. A key also matches if it ends in one of them after a
+	// '_', '-' or '.', e.g. "db_password". DefaultRedactKeys if nil.
+	Keys []string
+
+	// Patterns are redacted wherever they match in messages and string
+	// values. DefaultRedactPatterns if nil.
+	Patterns []*regexp.Regexp
+
+	Mode    RedactMode // How sensitive values are masked
+	HMACKey []byte     // Key of RedactHMAC, required in that mode
+}
+
+// Validate reports whether the options can be used, i.e. whether
+// a key is given in the RedactHMAC mode.
+func (o RedactOptions) Validate() error {
+	if o.Mode == RedactHMAC && len(o.HMACKey) == 0 {
+		return errors.New("suprelog: redaction in HMAC mode requires a key")
+	}
+	return nil
+}
+
+// redactor masks the sensitive values of records.
+type redactor struct {
+	keys     map[string]struct{}
+	patterns []*regexp.Regexp
+	mode     RedactMode
+	hmacKey  []byte
+}
+
+func newRedactor(opts RedactOptions) (*redactor, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Keys == nil {
+		opts.Keys = DefaultRedactKeys
+	}
+	if opts.Patterns == nil {
+		opts.Patterns = DefaultRedactPatterns
+	}
+
+	rd := &redactor{
+		keys:     make(map[string]struct{}, len(opts.Keys)),
+		patterns: opts.Patterns,
+		mode:     opts.Mode,
+		hmacKey:  opts.HMACKey,
+	}
+	for _, k := range opts.Keys {
+		rd.keys[strings.ToLower(k)] = struct{}{}
+	}
+	return rd, nil
+}
+
+// record returns a copy of r with its message and attributes redacted.
+func (rd *redactor) record(r slog.Record) slog.Record {
+	r2 := slog.NewRecord(r.Time, r.Level, rd.text(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		r2.AddAttrs(rd.attr(a))
+		return true
+	})
+	return r2
+}
+
+// attr returns a with the values of sensitive keys masked and the
+// patterns redacted in its string values.
+func (rd *redactor) attr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	sensitive := rd.sensitiveKey(a.Key)
+
+	switch {
+	case sensitive:
+		return rd.maskAttr(a.Key, v)
+	case v.Kind() == slog.KindGroup:
+		as := v.Group()
+		out := make([]slog.Attr, len(as))
+		for i, ga := range as {
+			out[i] = rd.attr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(out...)}
+	case v.Kind() == slog.KindString:
+		return slog.String(a.Key, rd.text(v.String()))
+	case v.Kind() == slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return slog.String(a.Key, rd.text(x.Error()))
+		case fmt.Stringer:
+			// Keep the value as is unless its text has to be masked
+			str := x.String()
+			if red := rd.text(str); red != str {
+				return slog.String(a.Key, red)
+			}
+		case []string:
+			return slog.Any(a.Key, rd.texts(x))
+		}
+		return slog.Attr{Key: a.Key, Value: v}
+	default:
+		return slog.Attr{Key: a.Key, Value: v}
+	}
+}
+
+// maskAttr masks the value v of a sensitive key, or all values of a group.
+func (rd *redactor) maskAttr(key string, v slog.Value) slog.Attr {
+	if v.Kind() != slog.KindGroup {
+		return slog.String(key, rd.mask(v.String()))
+	}
+	as := v.Group()
+	out := make([]slog.Attr, len(as))
+	for i, ga := range as {
+		out[i] = rd.maskAttr(ga.Key, ga.Value.Resolve())
+	}
+	return slog.Attr{Key: key, Value: slog.GroupValue(out...)}
+}
+
+// sensitiveKey reports whether the values of key are redacted.
+func (rd *redactor) sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if _, ok := rd.keys[key]; ok {
+		return true
+	}
+	if i := strings.LastIndexAny(key, "_-."); i >= 0 {
+		_, ok := rd.keys[key[i+1:]]
+		return ok
+	}
+	return false
+}
+
+// text returns s with the matches of the patterns masked.
+func (rd *redactor) text(s string) string {
+	for _, re := range rd.patterns {
+		if re == cardPattern {
+			s = re.ReplaceAllStringFunc(s, rd.maskCard)
+		} else {
+			s = re.ReplaceAllStringFunc(s, rd.mask)
+		}
+	}
+	return s
+}
+
+// maskCard returns the masked form of s if it is a valid card number.
+func (rd *redactor) maskCard(s string) string {
+	if !luhnValid(s) {
+		return s
+	}
+	return rd.mask(s)
+}
+
+// luhnValid reports whether the digits of s pass the Luhn check,
+// ignoring spaces and dashes.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 0 && sum%10 == 0
+}
+
+// texts returns ss with the patterns redacted in a copy,
+// or ss itself if none of them match.
+func (rd *redactor) texts(ss []string) []string {
+	var out []string
+	for i, str := range ss {
+		if red := rd.text(str); red != str {
+			if out == nil {
+				out = slices.Clone(ss)
+			}
+			out[i] = red
+		}
+	}
+	if out == nil {
+		return ss
+	}
+	return out
+}
+
+// mask returns the masked form of s.
+func (rd *redactor) mask(s string) string {
+	switch rd.mode {
+	case RedactPartial:
+		n := utf8.RuneCountInString(s)
+		if n <= 8 {
+			return redactMask
+		}
+		rs := []rune(s)
+		return strings.Repeat("*", 4) + string(rs[n-4:])
+	case RedactHMAC:
+		m := hmac.New(sha256.New, rd.hmacKey)
+		m.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(m.Sum(nil))[:16]
+	default:
+		return redactMask
+	}
+}
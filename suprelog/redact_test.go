@@ -0,0 +1,135 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHandler_Redaction(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithRedaction(RedactOptions{}),
+	)
+
+	log := slog.New(h).With("Authorization", "Basic dXNlcjpwYXNz")
+	log.Info("login by jane@example.com",
+		"user", "jane",
+		"db_password", "hunter2",
+		slog.Group("token", "id", "abc", "exp", 3600),
+		"card", "4111 1111 1111 1111",
+		"header", "Bearer eyJhbGciOi.eyJzdWIi.sig",
+		"err", errors.New("mail to bob@example.org failed"),
+		"count", 42,
+	)
+	NewClassic(h).Warn().Msg("charged card 4111-1111-1111-1111").Emit()
+
+	want := "login by *** | Authorization=*** user=jane db_password=*** token.id=*** token.exp=*** card=*** header=*** err=mail to *** failed count=42\n" +
+		"charged card ***\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandler_RedactionModes(t *testing.T) {
+	tests := []struct {
+		opts RedactOptions
+		want string
+	}{
+		{RedactOptions{Mode: RedactPartial}, `{"token":"****cdef","pin":"1234"}`},
+		{RedactOptions{Mode: RedactHMAC, HMACKey: []byte("k")}, `{"token":"hmac:`},
+		{RedactOptions{Keys: []string{"PIN"}, Patterns: []*regexp.Regexp{}}, `{"token":"0123456789abcdef","pin":"***"}`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		h := HandlerOptions(
+			WithWriter(&buf),
+			WithBuiltinSort([]string{}),
+			WithMode(NewMode().SetTyp(ModeJson)),
+			WithRedaction(tt.opts),
+		)
+		log := slog.New(h)
+		log.Info("msg", "token", "0123456789abcdef", "pin", "1234")
+		log.Info("msg", "token", "0123456789abcdef", "pin", "1234")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if !strings.Contains(lines[0], tt.want) || lines[0] != lines[1] {
+			t.Errorf("mode %d: got %q, want %s", tt.opts.Mode, lines, tt.want)
+		}
+	}
+}
+
+type redactStringer string
+
+func (s redactStringer) String() string { return string(s) }
+
+func TestHandler_RedactionHooksAndValues(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithRedaction(RedactOptions{}),
+		WithHooks(NewHook(func(ctx context.Context, r *slog.Record) error {
+			r.AddAttrs(slog.String("api_key", "s3cr3t"), slog.String("owner", "jane@example.com"))
+			return nil
+		})),
+	)
+
+	NewClassic(h).Info().Msg("sent").
+		Any("to", redactStringer("bob@example.org")).
+		Any("id", redactStringer("42")).
+		Strs("cc", []string{"carol@example.net", "team"}).
+		Emit()
+
+	want := "sent | to=*** id=42 cc=[*** team] api_key=*** owner=***\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithRedaction_MissingHMACKey(t *testing.T) {
+	opts := RedactOptions{Mode: RedactHMAC}
+	if err := opts.Validate(); err == nil {
+		t.Fatal("expected an error for HMAC mode without a key")
+	}
+
+	defer func() {
+		err, _ := recover().(error)
+		if err == nil || err.Error() != "suprelog: redaction in HMAC mode requires a key" {
+			t.Errorf("got panic %v, want the Validate error", err)
+		}
+	}()
+	HandlerOptions(WithRedaction(opts))
+}
+
+func TestHandler_RedactionCards(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithRedaction(RedactOptions{}),
+	)
+
+	slog.New(h).Info("paid",
+		"visa", "4111-1111-1111-1111",
+		"typo", "4111-1111-1111-1112",
+		"at_ms", 1760780000000,
+		"order", "order 1760780000123 shipped",
+		"trace", "4bf92f3577b34da6a3ce929d0e0e4736",
+	)
+
+	want := "paid | visa=*** typo=4111-1111-1111-1112 at_ms=1760780000000 order=order 1760780000123 shipped trace=4bf92f3577b34da6a3ce929d0e0e4736\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LevelSpec describes a named level, built in or registered with RegisterLevel.
+type LevelSpec struct {
+	Level Level  // Numeric value, comparable with the built-in levels
+	Name  string // Full name, e.g. "AUDIT"
+	Short string // Short name, e.g. "AUD", the full name if empty
+	Color string // Hexadecimal color of the level badge if the theme has none, e.g. "#8E44AD"
+}
+
+// levelTable is an immutable snapshot of the level registry.
+type levelTable struct {
+	specs  []LevelSpec // sorted by level
+	byName map[string]Level
+}
+
+var (
+	levelsMu  sync.Mutex // serializes registrations
+	levelsTab atomic.Pointer[levelTable]
+)
+
+func init() {
+	levelsTab.Store(newLevelTable([]LevelSpec{
+		{Level: LevelTrace, Name: "TRACE", Short: "TRC"},
+		{Level: LevelDebug, Name: "DEBUG", Short: "DBG"},
+		{Level: LevelInfo, Name: "INFO", Short: "INF"},
+		{Level: LevelNotice, Name: "NOTICE", Short: "NTC"},
+		{Level: LevelWarn, Name: "WARN", Short: "WRN"},
+		{Level: LevelError, Name: "ERROR", Short: "ERR"},
+		{Level: LevelFatal, Name: "FATAL", Short: "FTL"},
+	}))
+}
+
+func newLevelTable(specs []LevelSpec) *levelTable {
+	slices.SortFunc(specs, func(a, b LevelSpec) int { return int(a.Level - b.Level) })
+	t := &levelTable{specs: specs, byName: make(map[string]Level, 2*len(specs))}
+	for _, s := range specs {
+		t.byName[s.Name] = s.Level
+		t.byName[s.Short] = s.Level
+	}
+	return t
+}
+
+func currentLevels() *levelTable { return levelsTab.Load() }
+
+// RegisterLevel declares a named level, or renames a level that already has
+// a name. Names are upper-cased and must be unique, non-numeric and free of
+// spaces, '+' and '-'. The level is then known to String, ParseLevel, the
+// level badge colors and everything that compares levels.
+func RegisterLevel(spec LevelSpec) error {
+	spec.Name = strings.ToUpper(strings.TrimSpace(spec.Name))
+	spec.Short = strings.ToUpper(strings.TrimSpace(spec.Short))
+	if spec.Short == "" {
+		spec.Short = spec.Name
+	}
+	for _, name := range []string{spec.Name, spec.Short} {
+		if err := validLevelName(name); err != nil {
+			return err
+		}
+	}
+	if spec.Color != "" {
+		if _, _, _, err := parseHex(spec.Color); err != nil {
+			return fmt.Errorf("suprelog: level %s: %w", spec.Name, err)
+		}
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	old := currentLevels()
+	specs := make([]LevelSpec, 0, len(old.specs)+1)
+	for _, s := range old.specs {
+		if s.Level == spec.Level {
+			continue
+		}
+		for _, name := range []string{spec.Name, spec.Short} {
+			if name == s.Name || name == s.Short {
+				return fmt.Errorf("suprelog: level name %q is taken by %d", name, s.Level)
+			}
+		}
+		specs = append(specs, s)
+	}
+	levelsTab.Store(newLevelTable(append(specs, spec)))
+	return nil
+}
+
+func validLevelName(name string) error {
+	if name == "" {
+		return errors.New("suprelog: empty level name")
+	}
+	if strings.ContainsAny(name, " \t+-") {
+		return fmt.Errorf("suprelog: invalid level name %q", name)
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("suprelog: numeric level name %q", name)
+	}
+	return nil
+}
+
+// Levels returns the named levels in ascending order.
+func Levels() []LevelSpec {
+	return slices.Clone(currentLevels().specs)
+}
+
+// base returns the spec of the highest named level not above l, or of the
+// lowest named level if l is below all of them.
+func (t *levelTable) base(l Level) LevelSpec {
+	i, found := slices.BinarySearchFunc(t.specs, l, func(s LevelSpec, l Level) int { return int(s.Level - l) })
+	if !found && i > 0 {
+		i--
+	}
+	return t.specs[i]
+}
+
+// name returns the full or short name of l, relative to its base level if it has none.
+func (t *levelTable) name(l Level, short bool) string {
+	b := t.base(l)
+	name := b.Name
+	if short {
+		name = b.Short
+	}
+	switch d := int(l - b.Level); {
+	case d > 0:
+		return name + "+" + strconv.Itoa(d)
+	case d < 0:
+		return name + strconv.Itoa(d)
+	default:
+		return name
+	}
+}
@@ -0,0 +1,387 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RotateInterval is the period of time based log file rotation.
+type RotateInterval int
+
+// Time based rotation periods.
+const (
+	RotateNone   RotateInterval = iota // rotate by size only
+	RotateHourly                       // rotate at the start of every hour
+	RotateDaily                        // rotate at midnight
+)
+
+// Layout of the timestamp in the names of rotated files.
+const backupTimeFmt = "2006-01-02T15-04-05.000"
+
+// Suffix of compressed rotated files.
+const gzipExt = ".gz"
+
+// RotateWriter is an io.WriteCloser that writes to a log file and rotates
+// it by size, by time or both. A rotated file is renamed to
+// name-<timestamp>.ext in the same directory, optionally compressed with
+// gzip, and removed once it exceeds the retention limits.
+//
+// It can be passed to WithWriter, or to the Writer of a go-logger FileRecord.
+// The zero value is not usable, at least Filename must be set.
+type RotateWriter struct {
+	Filename   string         // File to write logs to, its directory is created if needed
+	MaxSize    int64          // Maximum size in bytes before the file is rotated, 0 disables
+	Interval   RotateInterval // Time based rotation period, RotateNone disables
+	MaxAge     time.Duration  // Maximum age of rotated files, 0 keeps them regardless of age
+	MaxBackups int            // Maximum number of rotated files, 0 keeps all of them
+	Compress   bool           // Indicates whether rotated files are compressed with gzip
+
+	mu       sync.Mutex
+	file     *os.File
+	closed   bool // set by Close, cleared by Reopen
+	size     int64
+	deadline time.Time // time of the next time based rotation
+	mill     sync.WaitGroup
+	millMu   sync.Mutex
+	now      func() time.Time
+}
+
+// Write writes p to the current log file, rotating it first if the
+// write would exceed MaxSize or the rotation interval has elapsed.
+// It returns os.ErrClosed once the writer has been closed.
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		if err := w.openExisting(int64(len(p))); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current log file, moves it aside and opens a new one.
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Reopen closes and reopens the log file without rotating it. It is meant
+// for external tools such as logrotate that move the file themselves.
+// It also reopens a writer that has been closed.
+func (w *RotateWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.close(); err != nil {
+		return err
+	}
+	if err := w.openExisting(0); err != nil {
+		return err
+	}
+	w.closed = false
+	return nil
+}
+
+// ReopenOnSignal reopens the log file whenever one of the given signals,
+// SIGHUP by default, is received. Calling the returned function stops it.
+func (w *RotateWriter) ReopenOnSignal(sig ...os.Signal) (stop func()) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sig...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				_ = w.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Close closes the current log file and waits for pending
+// compression and retention work to finish. Later writes fail
+// until the writer is reopened.
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	err := w.close()
+	w.mu.Unlock()
+
+	w.mill.Wait()
+	return err
+}
+
+func (w *RotateWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *RotateWriter) timeNow() time.Time {
+	if w.now != nil {
+		return w.now()
+	}
+	return time.Now()
+}
+
+func (w *RotateWriter) shouldRotate(n int64) bool {
+	if w.MaxSize > 0 && w.size > 0 && w.size+n > w.MaxSize {
+		return true
+	}
+	return w.Interval != RotateNone && !w.timeNow().Before(w.deadline)
+}
+
+// openExisting opens the log file for appending, rotating it first if it is
+// already too large to take n more bytes or was last written in a previous period.
+func (w *RotateWriter) openExisting(n int64) error {
+	if w.Filename == "" {
+		return errors.New("suprelog: RotateWriter requires a Filename")
+	}
+
+	info, err := os.Stat(w.Filename)
+	if os.IsNotExist(err) {
+		return w.openNew()
+	}
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(w.Filename, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	w.deadline = w.nextDeadline(info.ModTime())
+
+	if w.shouldRotate(n) {
+		return w.rotate()
+	}
+	return nil
+}
+
+// openNew creates a new empty log file.
+func (w *RotateWriter) openNew() error {
+	if err := os.MkdirAll(filepath.Dir(w.Filename), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(w.Filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.size = 0
+	w.deadline = w.nextDeadline(w.timeNow())
+	return nil
+}
+
+// nextDeadline returns the start of the rotation period following t.
+func (w *RotateWriter) nextDeadline(t time.Time) time.Time {
+	switch w.Interval {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+func (w *RotateWriter) rotate() error {
+	if err := w.close(); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(w.Filename); err == nil {
+		if err := os.Rename(w.Filename, w.backupName(w.timeNow())); err != nil {
+			return err
+		}
+	}
+
+	if err := w.openNew(); err != nil {
+		return err
+	}
+
+	now := w.timeNow()
+	w.mill.Add(1)
+	go func() {
+		defer w.mill.Done()
+		w.millRun(now)
+	}()
+	return nil
+}
+
+// backupName returns an unused name for the file rotated at the given time.
+func (w *RotateWriter) backupName(t time.Time) string {
+	dir, prefix, ext := w.nameParts()
+	for {
+		name := filepath.Join(dir, prefix+t.Local().Format(backupTimeFmt)+ext)
+		if !fileExists(name) && !fileExists(name+gzipExt) {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// nameParts splits Filename into its directory, the prefix of rotated
+// files and the extension, e.g. "logs", "app-" and ".log".
+func (w *RotateWriter) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(w.Filename)
+	base := filepath.Base(w.Filename)
+	ext = filepath.Ext(base)
+	prefix = strings.TrimSuffix(base, ext) + "-"
+	return dir, prefix, ext
+}
+
+// backupFile is a rotated log file together with its rotation time.
+type backupFile struct {
+	path string
+	at   time.Time
+}
+
+// millRun compresses rotated files and removes the ones exceeding
+// MaxBackups or MaxAge as of now. Errors are ignored, the files are
+// retried on the next rotation.
+func (w *RotateWriter) millRun(now time.Time) {
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+
+	backups, err := w.backups()
+	if err != nil {
+		return
+	}
+
+	// Newest first
+	sort.Slice(backups, func(i, j int) bool { return backups[i].at.After(backups[j].at) })
+
+	cutoff := now.Add(-w.MaxAge)
+	for i, b := range backups {
+		if w.MaxBackups > 0 && i >= w.MaxBackups || w.MaxAge > 0 && b.at.Before(cutoff) {
+			_ = os.Remove(b.path)
+			continue
+		}
+		if w.Compress && !strings.HasSuffix(b.path, gzipExt) {
+			_ = compressFile(b.path)
+		}
+	}
+}
+
+// backups lists the rotated files of Filename.
+func (w *RotateWriter) backups() ([]backupFile, error) {
+	dir, prefix, ext := w.nameParts()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), gzipExt)
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		at, err := time.ParseInLocation(backupTimeFmt, strings.TrimSuffix(stamp, ext), time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), at: at})
+	}
+	return backups, nil
+}
+
+// compressFile gzips src into src.gz and removes src.
+func compressFile(src string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	dst := src + gzipExt
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	_ = in.Close()
+	return os.Remove(src)
+}
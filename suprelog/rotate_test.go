@@ -0,0 +1,170 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotateWriter_Size(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2023, 8, 21, 10, 0, 0, 0, time.Local)
+	w := &RotateWriter{
+		Filename:   filepath.Join(dir, "logs", "app.log"),
+		MaxSize:    10,
+		MaxBackups: 2,
+		Compress:   true,
+		now:        func() time.Time { return now },
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"app-2023-08-21T10-00-00.001.log.gz",
+		"app-2023-08-21T10-00-00.002.log.gz",
+		"app.log",
+	}
+	got := listDir(t, filepath.Join(dir, "logs"))
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "logs", "app.log"))
+	if string(data) != "fourth\n" {
+		t.Errorf("current file: got %q", data)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "logs", want[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(gz); string(data) != "third\n" {
+		t.Errorf("compressed backup: got %q", data)
+	}
+}
+
+func TestRotateWriter_Interval(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2023, 8, 21, 10, 30, 0, 0, time.Local)
+	w := &RotateWriter{
+		Filename: filepath.Join(dir, "app.log"),
+		Interval: RotateHourly,
+		MaxAge:   30 * time.Minute,
+		now:      func() time.Time { return now },
+	}
+	defer w.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := w.Write([]byte("line\n")); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Hour)
+	}
+	w.mill.Wait()
+
+	want := []string{"app-2023-08-21T12-30-00.000.log", "app.log"}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestRotateWriter_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w := &RotateWriter{Filename: name}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate logrotate moving the file away
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("after\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(name); string(data) != "after\n" {
+		t.Errorf("reopened file: got %q", data)
+	}
+	if data, _ := os.ReadFile(name + ".1"); string(data) != "before\n" {
+		t.Errorf("moved file: got %q", data)
+	}
+}
+
+func TestRotateWriter_WriteAfterClose(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w := &RotateWriter{Filename: name}
+
+	if _, err := w.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte("late\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("write after close: got %v, want %v", err, os.ErrClosed)
+	}
+	if err := w.Rotate(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("rotate after close: got %v, want %v", err, os.ErrClosed)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("file was recreated after close: %v", err)
+	}
+
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("reopened\n")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(name); string(data) != "reopened\n" {
+		t.Errorf("reopened file: got %q", data)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingOptions configures a SamplingHandler. Counter sampling and
+// probabilistic sampling can be combined, a record is then written only
+// if both keep it.
+type SamplingOptions struct {
+	// Counter sampling, in the style of zap: in each Tick window the First
+	// records with the same level and message are written, after that only
+	// every Thereafter-th one. It is disabled if First and Thereafter are 0.
+	Tick       time.Duration // Length of the window, 1s if not positive
+	First      int           // Number of records written at the start of a window
+	Thereafter int           // Write every Thereafter-th record after First, 0 drops them all
+
+	// Probabilistic sampling keeps a record with probability Rate. It is
+	// disabled if Rate is not in (0, 1). If KeyFunc returns a non-empty key,
+	// such as a trace or request ID, the decision is derived from the key,
+	// so that the records of one request are either all kept or all dropped.
+	Rate    float64
+	KeyFunc func(ctx context.Context) string
+
+	// ReportInterval is the period at which the numbers of sampled out
+	// records are written as a WARN record, 0 disables the report.
+	ReportInterval time.Duration
+}
+
+// Number of counters records are hashed into.
+const samplingCounters = 4096
+
+// SamplingHandler is a slog.Handler that writes only a sample of the
+// records of a high-volume stream to the wrapped handler. FATAL records
+// are never sampled out.
+type SamplingHandler struct {
+	handler slog.Handler
+	core    *samplingCore
+}
+
+// samplingCore is the state shared by a SamplingHandler and the handlers
+// derived from it with WithAttrs and WithGroup.
+type samplingCore struct {
+	opts     SamplingOptions
+	counters [samplingCounters]samplingCounter
+	mu       sync.Mutex
+	dropped  map[slog.Level]uint64
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// samplingCounter counts the records of one window.
+type samplingCounter struct {
+	resetAt atomic.Int64
+	n       atomic.Uint64
+}
+
+// NewSamplingHandler returns a SamplingHandler that wraps h. If a report
+// interval is configured, Close must be called to stop reporting.
+func NewSamplingHandler(h slog.Handler, opts SamplingOptions) *SamplingHandler {
+	if h == nil {
+		panic("nil Handler")
+	}
+	if opts.Tick <= 0 {
+		opts.Tick = time.Second
+	}
+
+	c := &samplingCore{
+		opts:    opts,
+		dropped: map[slog.Level]uint64{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	s := &SamplingHandler{handler: h, core: c}
+
+	if opts.ReportInterval > 0 {
+		go s.report()
+	} else {
+		close(c.done)
+	}
+	return s
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (s *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.handler.Enabled(ctx, level)
+}
+
+// Handle passes r to the wrapped handler if it is sampled in.
+func (s *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < LevelFatal.Level() && !s.core.sample(ctx, r) {
+		s.core.mu.Lock()
+		s.core.dropped[r.Level]++
+		s.core.mu.Unlock()
+		return nil
+	}
+	return s.handler.Handle(ctx, r)
+}
+
+// callerPC resolves the source position of the wrapped handler, if it does.
+func (s *SamplingHandler) callerPC(pc uintptr) uintptr {
+	if c, ok := s.handler.(callerResolver); ok {
+		return c.callerPC(pc)
+	}
+	return pc
+}
+
+// captureStack captures the stack of r for the wrapped handler, if it writes one.
+func (s *SamplingHandler) captureStack(ctx context.Context, r slog.Record) context.Context {
+	if c, ok := s.handler.(stackCapturer); ok {
+		return c.captureStack(ctx, r)
+	}
+	return ctx
+}
+
+// runFatal runs the fatal hook of the wrapped handler, if it has one.
+func (s *SamplingHandler) runFatal(ctx context.Context, r slog.Record) int {
+	if f, ok := s.handler.(fatalRunner); ok {
+		return f.runFatal(ctx, r)
+	}
+	return -1
+}
+
+// WithAttrs returns a SamplingHandler sharing the counters of s whose
+// wrapped handler has the given attributes.
+func (s *SamplingHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	return &SamplingHandler{handler: s.handler.WithAttrs(as), core: s.core}
+}
+
+// WithGroup returns a SamplingHandler sharing the counters of s whose
+// wrapped handler has the given group.
+func (s *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{handler: s.handler.WithGroup(name), core: s.core}
+}
+
+// Dropped returns the number of records sampled out per level since the last report.
+func (s *SamplingHandler) Dropped() map[slog.Level]uint64 {
+	s.core.mu.Lock()
+	defer s.core.mu.Unlock()
+
+	m := make(map[slog.Level]uint64, len(s.core.dropped))
+	for l, n := range s.core.dropped {
+		m[l] = n
+	}
+	return m
+}
+
+// Close writes a final report and stops the periodic reporting.
+func (s *SamplingHandler) Close() error {
+	s.core.once.Do(func() { close(s.core.stop) })
+	<-s.core.done
+	return nil
+}
+
+// report writes the numbers of sampled out records every ReportInterval.
+func (s *SamplingHandler) report() {
+	defer close(s.core.done)
+
+	ticker := time.NewTicker(s.core.opts.ReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.writeReport()
+		case <-s.core.stop:
+			s.writeReport()
+			return
+		}
+	}
+}
+
+func (s *SamplingHandler) writeReport() {
+	s.core.mu.Lock()
+	dropped := s.core.dropped
+	s.core.dropped = map[slog.Level]uint64{}
+	s.core.mu.Unlock()
+
+	if len(dropped) == 0 {
+		return
+	}
+
+	var total uint64
+	as := make([]slog.Attr, 0, len(dropped))
+	for _, l := range []Level{LevelTrace, LevelDebug, LevelInfo, LevelNotice, LevelWarn, LevelError} {
+		if n, ok := dropped[l.Level()]; ok {
+			as = append(as, slog.Uint64(l.String(), n))
+			total += n
+			delete(dropped, l.Level())
+		}
+	}
+	for l, n := range dropped {
+		as = append(as, slog.Uint64(Level(l).String(), n))
+		total += n
+	}
+
+	ctx := context.Background()
+	if !s.handler.Enabled(ctx, LevelWarn.Level()) {
+		return
+	}
+	r := slog.NewRecord(time.Now(), LevelWarn.Level(), "suprelog: records sampled out", 0)
+	r.AddAttrs(slog.Uint64("dropped", total), slog.Attr{Key: "levels", Value: slog.GroupValue(as...)})
+	_ = s.handler.Handle(ctx, r)
+}
+
+// sample reports whether r is kept.
+func (c *samplingCore) sample(ctx context.Context, r slog.Record) bool {
+	if c.opts.First > 0 || c.opts.Thereafter > 0 {
+		n := c.counter(r).inc(r.Time, c.opts.Tick)
+		if n > uint64(c.opts.First) {
+			if c.opts.Thereafter <= 0 || (n-uint64(c.opts.First))%uint64(c.opts.Thereafter) != 0 {
+				return false
+			}
+		}
+	}
+
+	if rate := c.opts.Rate; rate > 0 && rate < 1 {
+		var key string
+		if c.opts.KeyFunc != nil {
+			key = c.opts.KeyFunc(ctx)
+		}
+		if key == "" {
+			return rand.Float64() < rate
+		}
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		return float64(mix64(h.Sum64())) < rate*math.MaxUint64
+	}
+	return true
+}
+
+// counter returns the counter for the level and message of r.
+func (c *samplingCore) counter(r slog.Record) *samplingCounter {
+	h := fnv.New32a()
+	h.Write([]byte(r.Message))
+	i := (h.Sum32() + uint32(r.Level-slog.Level(LevelTrace))) % samplingCounters
+	return &c.counters[i]
+}
+
+// inc counts a record logged at t and returns its number in the current window.
+func (sc *samplingCounter) inc(t time.Time, tick time.Duration) uint64 {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	now := t.UnixNano()
+
+	resetAt := sc.resetAt.Load()
+	if resetAt > now {
+		return sc.n.Add(1)
+	}
+
+	sc.n.Store(1)
+	if !sc.resetAt.CompareAndSwap(resetAt, now+tick.Nanoseconds()) {
+		// Another goroutine started the window
+		return sc.n.Add(1)
+	}
+	return 1
+}
+
+// mix64 spreads similar hashes, such as those of sequential IDs, over
+// the whole range. It is the finalizer of splitmix64.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler_Counter(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{}))
+	s := NewSamplingHandler(h, SamplingOptions{Tick: time.Hour, First: 2, Thereafter: 3})
+
+	log := slog.New(s)
+	for i := 1; i <= 10; i++ {
+		log.Info("hot", "i", i)
+		log.Warn("other", "i", i)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var hot []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "hot") {
+			hot = append(hot, strings.TrimPrefix(line, "hot | "))
+		}
+	}
+	if got, want := strings.Join(hot, ","), "i=1,i=2,i=5,i=8"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got := s.Dropped()[slog.LevelInfo]; got != 6 {
+		t.Errorf("dropped INFO %d, want 6", got)
+	}
+}
+
+func TestSamplingHandler_RateByKey(t *testing.T) {
+	type traceKey struct{}
+	var buf bytes.Buffer
+	h := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{}))
+	s := NewSamplingHandler(h, SamplingOptions{
+		Rate:    0.5,
+		KeyFunc: func(ctx context.Context) string { s, _ := ctx.Value(traceKey{}).(string); return s },
+	})
+
+	log := slog.New(s)
+	kept := 0
+	for i := 0; i < 200; i++ {
+		ctx := context.WithValue(context.Background(), traceKey{}, fmt.Sprintf("trace-%d", i))
+		before := buf.Len()
+		for j := 0; j < 5; j++ {
+			log.InfoContext(ctx, "request")
+		}
+		switch n := strings.Count(buf.String()[before:], "\n"); n {
+		case 0:
+		case 5:
+			kept++
+		default:
+			t.Fatalf("trace %d: %d of 5 records kept", i, n)
+		}
+	}
+	if kept < 50 || kept > 150 {
+		t.Errorf("kept %d of 200 traces at rate 0.5", kept)
+	}
+}
+
+func TestSamplingHandler_Report(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(WithWriter(&buf), WithBuiltinSort([]string{FieldLevel}))
+	s := NewSamplingHandler(h, SamplingOptions{Tick: time.Hour, First: 1, ReportInterval: time.Hour})
+
+	log := slog.New(s)
+	for i := 0; i < 3; i++ {
+		log.Debug("hot")
+	}
+	_ = s.Close()
+
+	want := "[DEBUG] | hot\n[WARN] | suprelog: records sampled out | dropped=2 levels.DEBUG=2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSamplingHandler_FatalBehindFanout(t *testing.T) {
+	if os.Getenv(fatalEnv) != "" {
+		h := HandlerOptions(
+			WithWriter(os.Stdout),
+			WithBuiltinSort([]string{}),
+			WithExitCode(3),
+			WithFatalHook(func(ctx context.Context, rec slog.Record) error {
+				fmt.Println("fatal hook ran")
+				return nil
+			}),
+		)
+		s := NewSamplingHandler(h, SamplingOptions{First: 1})
+		slog.New(NewFanoutHandler(s)).Log(context.Background(), LevelFatal.Level(), "bye")
+		return
+	}
+
+	out, code := runFatalChild(t)
+	if code != 3 || !strings.Contains(out, "bye\nfatal hook ran\n") {
+		t.Errorf("exit code %d, want 3, output:\n%s", code, out)
+	}
+}
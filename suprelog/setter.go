@@ -13,7 +13,15 @@ import (
 
 // SetLogLevel sets the log level of the handler to the specified level.
 func (h *Handler) SetLogLevel(l Level) *Handler {
-	h.Level = l
+	h.level.Set(l)
+	return h
+}
+
+// SetVModule replaces the log level overrides by source file of the
+// handler and the handlers derived from it. It is safe to call while
+// logging.
+func (h *Handler) SetVModule(rules ...VModuleRule) *Handler {
+	h.vmodule.Store(newVModule(rules))
 	return h
 }
 
@@ -35,12 +43,46 @@ func (h *Handler) SetColorScale(cs *ColorScale) *Handler {
 	return h
 }
 
+// SetColorProfile sets the colors of the output terminal,
+// ColorAuto detects them from the writer of the handler.
+func (h *Handler) SetColorProfile(p ColorProfile) *Handler {
+	if p == ColorAuto {
+		p = DetectColorProfile(h.w)
+	}
+	h.colorProfile = p
+	return h
+}
+
+// SetStackTrace sets whether stack traces are written for the records at or above the stack level.
+func (h *Handler) SetStackTrace(enable bool) *Handler {
+	h.stackTrace = enable
+	return h
+}
+
+// SetStackLevel sets the lowest level of the records written with a stack trace.
+func (h *Handler) SetStackLevel(l Level) *Handler {
+	h.stackLevel = l
+	return h
+}
+
 // SetFatalHook sets the fatal hook function to be executed before program exit on fatal logs.
 func (h *Handler) SetFatalHook(hook func(ctx context.Context, rec slog.Record) error) *Handler {
 	h.onFatal = hook
 	return h
 }
 
+// AddHooks adds hooks to be run for the records at their levels.
+func (h *Handler) AddHooks(hooks ...Hook) *Handler {
+	h.hooks = append(h.hooks, hooks...)
+	return h
+}
+
+// SetDefault sets whether loggers initialized from the handler install it as the slog default.
+func (h *Handler) SetDefault(enable bool) *Handler {
+	h.setDefault = enable
+	return h
+}
+
 // ToggleLogPath toggles between using absolute and relative paths in log locations.
 func (h *Handler) ToggleLogPath() *Handler {
 	internal.Ternary(
@@ -0,0 +1,99 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pokeyaro/gopkg/suprelog/callstack"
+	"github.com/pokeyaro/gopkg/suprelog/internal"
+)
+
+// KeyStack is the key of the stack trace of a record.
+const KeyStack = "stack"
+
+// stackAttr returns the stack trace of the goroutine from the call site pc
+// outwards, after ReplaceAttr, or an empty attribute if pc is not on the
+// stack of the goroutine. A stack captured before the record was queued,
+// e.g. by an AsyncHandler, is taken from ctx instead.
+func (h *Handler) stackAttr(ctx context.Context, pc uintptr) slog.Attr {
+	frames, ok := ctx.Value(stackKey{}).([]runtime.Frame)
+	if !ok {
+		frames = callstack.Stack(pc)
+	}
+	if len(frames) == 0 {
+		return slog.Attr{}
+	}
+
+	// One function per line, followed by its file:line indented by a tab
+	var b strings.Builder
+	for i, frame := range frames {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Function[strings.LastIndexByte(frame.Function, '/')+1:])
+		b.WriteString("\n\t")
+		b.WriteString(h.stackFile(frame.File))
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+	}
+
+	a := slog.String(KeyStack, b.String())
+	if h.replaceAttr != nil {
+		a = h.replaceAttr(nil, a)
+	}
+	return a
+}
+
+// stackKey is the context key of the stack captured for a record before
+// it is handed to another goroutine.
+type stackKey struct{}
+
+// stackCapturer is implemented by handlers that write stack traces, see
+// Handler.captureStack.
+type stackCapturer interface {
+	captureStack(ctx context.Context, r slog.Record) context.Context
+}
+
+// captureStack returns ctx with the stack of r, whose PC must be resolved,
+// if the handler writes stack traces for its level. It must be called on
+// the goroutine that logged r.
+func (h *Handler) captureStack(ctx context.Context, r slog.Record) context.Context {
+	if !h.stackTrace || r.Level < h.stackLevel.Level() {
+		return ctx
+	}
+	if _, ok := ctx.Value(stackKey{}).([]runtime.Frame); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, stackKey{}, callstack.Stack(r.PC))
+}
+
+// stackFile shortens the files of the standard library and of the module
+// cache, and the files of the project as the log position does.
+func (h *Handler) stackFile(file string) string {
+	if short, ok := callstack.ShortFile(file); ok || h.absPath {
+		return short
+	}
+	projectRoot, err := internal.GetProjectRoot()
+	if err != nil {
+		return file
+	}
+	return relativePath(file, projectRoot)
+}
+
+// appendStack writes the stack trace as a block below the record,
+// each line indented by a tab.
+func (s *handleState) appendStack(stack string) {
+	for more := true; more; {
+		var line string
+		line, stack, more = strings.Cut(stack, "\n")
+		s.buf.WriteString("\n\t")
+		s.buf.WriteString(line)
+	}
+}
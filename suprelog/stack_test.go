@@ -0,0 +1,120 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pokeyaro/gopkg/suprelog/callstack"
+)
+
+func TestHandler_StackTrace(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewEntry(HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithMode(NewMode().SetTyp(ModeNdjson)),
+		WithStackTrace(true),
+	))
+
+	log.Warn("below the stack level")
+	log.Error("failed")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %s", len(lines), buf.String())
+	}
+	if strings.Contains(lines[0], `"stack"`) {
+		t.Errorf("WARN record has a stack trace: %s", lines[0])
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &m); err != nil {
+		t.Fatal(err)
+	}
+	stack, _ := m[KeyStack].(string)
+	if !strings.HasPrefix(stack, "suprelog.TestHandler_StackTrace\n\tsuprelog/stack_test.go:") {
+		t.Errorf("stack does not start at the call site:\n%s", stack)
+	}
+	for _, frame := range []string{"(*Handler).Handle", "(*Entry).Error", "slog.", "runtime.goexit"} {
+		if strings.Contains(stack, frame) {
+			t.Errorf("stack contains %s:\n%s", frame, stack)
+		}
+	}
+	if !strings.Contains(stack, "testing.tRunner\n\ttesting/testing.go:") {
+		t.Errorf("stack does not shorten the GOROOT files:\n%s", stack)
+	}
+}
+
+func TestHandler_StackTraceText(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewClassic(HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{FieldLevel}),
+		WithMode(NewMode().SetLog(ModeSimplify)),
+		WithStackTrace(true),
+		WithStackLevel(LevelWarn),
+	))
+
+	log.Warn().Int("n", 1).Msg("slow").Emit()
+
+	got := buf.String()
+	if !strings.HasPrefix(got, "[WARN] | slow | n=1\n\tsuprelog.TestHandler_StackTraceText\n\t\tsuprelog/stack_test.go:") {
+		t.Errorf("stack is not an indented block below the record:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "\n") || strings.HasSuffix(got, "\n\n") {
+		t.Errorf("record does not end with a single newline: %q", got)
+	}
+}
+
+func TestHandler_StackTraceAsync(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithMode(NewMode().SetTyp(ModeNdjson)),
+		WithStackTrace(true),
+	)
+	a := NewAsyncHandler(h, AsyncOptions{})
+	f := NewFanoutHandlerWithOptions(FanoutOptions{Isolate: true}, h)
+
+	NewEntry(a).Error("queued")
+	NewEntry(f).Error("isolated")
+	_ = a.Close()
+	_ = f.Close()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatal(err)
+		}
+		stack, _ := m[KeyStack].(string)
+		if !strings.HasPrefix(stack, "suprelog.TestHandler_StackTraceAsync\n\tsuprelog/stack_test.go:") {
+			t.Errorf("%s: stack does not start at the call site:\n%s", m["msg"], stack)
+		}
+	}
+}
+
+func TestShortFile(t *testing.T) {
+	cases := []struct {
+		file, want string
+		ok         bool
+	}{
+		{"/root/go/pkg/mod/github.com/goccy/go-json@v0.10.2/json.go", "github.com/goccy/go-json@v0.10.2/json.go", true},
+		{"/home/app/main.go", "/home/app/main.go", false},
+	}
+	for _, c := range cases {
+		if got, ok := callstack.ShortFile(c.file); got != c.want || ok != c.ok {
+			t.Errorf("ShortFile(%q) = %q, %v, want %q, %v", c.file, got, ok, c.want, c.ok)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ColorProfile is the set of colors a terminal can display.
+type ColorProfile int
+
+// Color profiles.
+const (
+	ColorAuto      ColorProfile = iota // detect the profile with DetectColorProfile
+	ColorNone                          // no colors
+	Color16                            // the 16 basic ANSI colors
+	Color256                           // the 256 color palette
+	ColorTrueColor                     // 24-bit RGB colors
+)
+
+// DetectColorProfile returns the color profile of the terminal w writes to.
+//
+// NO_COLOR, if not empty, turns colors off. FORCE_COLOR turns them on even
+// if w is not a terminal, with the profile given by its value: 0 or false
+// for none, 1 for 16 colors, 2 for 256 colors, 3 for truecolor, and
+// otherwise the profile of the terminal. Writers other than terminals get
+// no colors, a terminal gets truecolor if COLORTERM is truecolor or 24bit,
+// 256 colors if TERM mentions 256color and 16 colors otherwise.
+func DetectColorProfile(w io.Writer) ColorProfile {
+	if os.Getenv("NO_COLOR") != "" {
+		return ColorNone
+	}
+
+	force, forced := os.LookupEnv("FORCE_COLOR")
+	if forced {
+		switch strings.ToLower(force) {
+		case "0", "false":
+			return ColorNone
+		case "1":
+			return Color16
+		case "2":
+			return Color256
+		case "3":
+			return ColorTrueColor
+		}
+	} else if !isTerminal(w) {
+		return ColorNone
+	}
+
+	term := os.Getenv("TERM")
+	switch {
+	case term == "dumb" && !forced:
+		return ColorNone
+	case strings.EqualFold(os.Getenv("COLORTERM"), "truecolor"), strings.EqualFold(os.Getenv("COLORTERM"), "24bit"):
+		return ColorTrueColor
+	case strings.Contains(term, "256color"):
+		return Color256
+	default:
+		return Color16
+	}
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// background returns the escape sequence setting the background color
+// closest to r, g, b within the profile, or "" for no colors.
+func (p ColorProfile) background(r, g, b int) string {
+	return p.sgr(r, g, b, 48)
+}
+
+// foreground returns the escape sequence setting the text color
+// closest to r, g, b within the profile, or "" for no colors.
+func (p ColorProfile) foreground(r, g, b int) string {
+	return p.sgr(r, g, b, 38)
+}
+
+// sgr returns the escape sequence setting the extended color r, g, b,
+// where layer is 38 for the text and 48 for the background.
+func (p ColorProfile) sgr(r, g, b, layer int) string {
+	switch p {
+	case ColorTrueColor:
+		return fmt.Sprintf("\033[%d;2;%d;%d;%dm", layer, r, g, b)
+	case Color256:
+		closestColor := (r*6/256)*36 + (g*6/256)*6 + (b * 6 / 256)
+		return fmt.Sprintf("\033[%d;5;%dm", layer, 16+closestColor)
+	case Color16:
+		// 30-37 and 90-97 for the text, 40-47 and 100-107 for the background
+		code := ansi16Code(r, g, b) + layer - 38
+		return fmt.Sprintf("\033[%dm", code)
+	default:
+		return ""
+	}
+}
+
+// ansi16 are the colors of the 16 basic ANSI colors in the xterm palette.
+var ansi16 = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// ansi16Code returns the SGR text color code of the basic color closest to r, g, b.
+func ansi16Code(r, g, b int) int {
+	best, bestDist := 0, -1
+	for i, c := range ansi16 {
+		dr, dg, db := r-c[0], g-c[1], b-c[2]
+		if d := dr*dr + dg*dg + db*db; bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	if best < 8 {
+		return 30 + best
+	}
+	return 90 + best - 8
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDetectColorProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected ColorProfile
+	}{
+		{"not a terminal", nil, ColorNone},
+		{"no color", map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "3"}, ColorNone},
+		{"force off", map[string]string{"FORCE_COLOR": "0"}, ColorNone},
+		{"force 16", map[string]string{"FORCE_COLOR": "1"}, Color16},
+		{"force 256", map[string]string{"FORCE_COLOR": "2"}, Color256},
+		{"force truecolor", map[string]string{"FORCE_COLOR": "3"}, ColorTrueColor},
+		{"force colorterm", map[string]string{"FORCE_COLOR": "", "COLORTERM": "truecolor"}, ColorTrueColor},
+		{"force term", map[string]string{"FORCE_COLOR": "yes", "TERM": "xterm-256color"}, Color256},
+		{"force dumb", map[string]string{"FORCE_COLOR": "yes", "TERM": "dumb"}, Color16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"NO_COLOR", "FORCE_COLOR", "COLORTERM", "TERM"} {
+				t.Setenv(k, "")
+			}
+			os.Unsetenv("FORCE_COLOR")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := DetectColorProfile(&bytes.Buffer{}); got != tt.expected {
+				t.Errorf("got %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestColorProfileBackground(t *testing.T) {
+	tests := []struct {
+		profile  ColorProfile
+		expected string
+	}{
+		{ColorTrueColor, "\033[48;2;245;63;63m"},
+		{Color256, "\033[48;5;203m"},
+		{Color16, "\033[101m"},
+		{ColorNone, ""},
+	}
+
+	for _, tt := range tests {
+		// #F53F3F
+		if got := tt.profile.sgr(245, 63, 63, 48); got != tt.expected {
+			t.Errorf("profile %d: got %q, want %q", tt.profile, got, tt.expected)
+		}
+	}
+}
+
+func TestHandlerColorProfile(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{FieldLevel}),
+		WithColorful(true),
+		WithColorScale(NewColorScale()),
+	)
+	NewClassic(h).Level(LevelInfo).Msg("piped").Emit()
+	if got, want := buf.String(), "[INFO] | piped\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	h.SetColorProfile(ColorTrueColor)
+	NewClassic(h).Level(LevelInfo).Msg("forced").Emit()
+	if got := buf.String(); !strings.HasPrefix(got, "\033[48;2;") {
+		t.Errorf("got %q, want a truecolor badge", got)
+	}
+
+	buf.Reset()
+	h.SetColorProfile(ColorAuto)
+	NewClassic(h).Level(LevelInfo).Msg("detected").Emit()
+	if got, want := buf.String(), "[INFO] | detected\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
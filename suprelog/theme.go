@@ -0,0 +1,169 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+// Registered color themes by name.
+var (
+	themesMu sync.RWMutex
+	themes   = map[string]*ColorScale{}
+)
+
+func init() {
+	for _, name := range []string{chinese, arco, ant, ele, okabeIto, hiContrast} {
+		themes[name] = builtinTheme(name)
+	}
+}
+
+// ColorTheme returns a copy of the registered theme with the given name.
+// For an unknown name it writes a warning to stderr and returns the
+// Chinese colors theme; use LookupTheme to handle the error instead.
+func ColorTheme(theme string) *ColorScale {
+	cs, err := LookupTheme(theme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v, using %s\n", err, chinese)
+		cs, _ = LookupTheme(chinese)
+	}
+	return cs
+}
+
+// LookupTheme returns a copy of the registered theme with the given name.
+func LookupTheme(name string) (*ColorScale, error) {
+	themesMu.RLock()
+	cs, ok := themes[name]
+	themesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("suprelog: unknown theme %q", name)
+	}
+	return cs.clone(), nil
+}
+
+// RegisterTheme validates cs and registers a copy of it under the given
+// name, replacing the theme of that name if there is one. The built-in
+// themes are chinese, arco, ant, element, the color-blind-safe okabe-ito
+// and high-contrast.
+func RegisterTheme(name string, cs *ColorScale) error {
+	if name = strings.TrimSpace(name); name == "" {
+		return errors.New("suprelog: empty theme name")
+	}
+	if cs == nil {
+		return fmt.Errorf("suprelog: theme %s: nil color scale", name)
+	}
+	if err := cs.Validate(); err != nil {
+		return fmt.Errorf("suprelog: theme %s: %w", name, err)
+	}
+
+	themesMu.Lock()
+	defer themesMu.Unlock()
+	themes[name] = cs.clone()
+	return nil
+}
+
+// LoadThemeFile registers the theme of the JSON file path and returns its
+// name. The file holds a ColorScale and an optional "name", which defaults
+// to the file name without its extension, e.g.
+//
+//	{"name": "mono", "colors": [{"level": "ERROR", "hex": "#FF0000"}], "key": "#808080"}
+func LoadThemeFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("suprelog: %w", err)
+	}
+	defer f.Close()
+
+	var file struct {
+		Name string `json:"name"`
+		ColorScale
+	}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return "", fmt.Errorf("suprelog: %s: %w", path, err)
+	}
+
+	name := file.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return name, RegisterTheme(name, &file.ColorScale)
+}
+
+// Themes returns the names of the registered themes in alphabetical order.
+func Themes() []string {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Validate reports all invalid colors of cs.
+func (cs *ColorScale) Validate() error {
+	var errs []error
+	for _, item := range cs.Colors {
+		if strings.TrimSpace(item.Level) == "" {
+			errs = append(errs, errors.New("color without a level"))
+			continue
+		}
+		if cs.IsRGB {
+			if err := validRGB(item.RGB); err != nil {
+				errs = append(errs, fmt.Errorf("level %s: %w", item.Level, err))
+			}
+		} else if _, _, _, err := parseHex(item.Hex); err != nil {
+			errs = append(errs, fmt.Errorf("level %s: %w", item.Level, err))
+		}
+	}
+
+	for _, c := range []struct{ part, hex string }{
+		{"time", cs.Time},
+		{"pos", cs.Pos},
+		{"message", cs.Message},
+		{"key", cs.Key},
+		{"value", cs.Value},
+	} {
+		if c.hex == "" {
+			continue
+		}
+		if _, _, _, err := parseHex(c.hex); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.part, err))
+		}
+	}
+
+	for i, rule := range cs.Rules {
+		if rule.Match == nil {
+			errs = append(errs, fmt.Errorf("rule %d: no match function", i))
+		}
+		if _, _, _, err := parseHex(rule.Color); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// clone returns a deep copy of cs.
+func (cs *ColorScale) clone() *ColorScale {
+	cs2 := *cs
+	cs2.Colors = slices.Clone(cs.Colors)
+	for i := range cs2.Colors {
+		cs2.Colors[i].RGB = slices.Clone(cs2.Colors[i].RGB)
+	}
+	cs2.Rules = slices.Clone(cs.Rules)
+	return &cs2
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinThemes(t *testing.T) {
+	names := Themes()
+	for _, name := range []string{"chinese", "arco", "ant", "element", "okabe-ito", "high-contrast"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("missing built-in theme %q in %v", name, names)
+			continue
+		}
+		cs, _ := LookupTheme(name)
+		if err := cs.Validate(); err != nil {
+			t.Errorf("theme %s: %v", name, err)
+		}
+	}
+
+	if _, err := LookupTheme("nope"); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	cs := ColorTheme("nope")
+	os.Stderr = stderr
+	w.Close()
+	warning, _ := io.ReadAll(r)
+
+	if cs.Colors[0].Hex != NewColorScale().Colors[0].Hex {
+		t.Error("expected the Chinese colors for an unknown theme")
+	}
+	if got, want := string(warning), "suprelog: unknown theme \"nope\", using chinese\n"; got != want {
+		t.Errorf("warning %q, want %q", got, want)
+	}
+}
+
+func TestRegisterTheme(t *testing.T) {
+	err := RegisterTheme("broken", &ColorScale{
+		IsRGB:  true,
+		Colors: []ColorItem{{Level: "INFO", RGB: []int{1, 2}}, {RGB: []int{1, 2, 3}}},
+		Key:    "red",
+		Rules:  []ColorRule{{Color: "#FF0000"}},
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"level INFO", "color without a level", "key:", "rule 0: no match function"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	if slices.Contains(Themes(), "broken") {
+		t.Error("an invalid theme was registered")
+	}
+
+	cs := &ColorScale{Colors: []ColorItem{{Level: "INFO", Hex: "#FFFFFF"}}}
+	if err := RegisterTheme("white", cs); err != nil {
+		t.Fatal(err)
+	}
+	cs.Colors[0].Hex = "#000000"
+	got, err := LookupTheme("white")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Colors[0].Hex != "#FFFFFF" {
+		t.Errorf("the registered theme changed with its source: %q", got.Colors[0].Hex)
+	}
+	got.Colors[0].Hex = "#000000"
+	if again, _ := LookupTheme("white"); again.Colors[0].Hex != "#FFFFFF" {
+		t.Error("the registered theme changed with a lookup")
+	}
+}
+
+func TestLoadThemeFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "solar.json")
+	data := `{"colors": [{"level": "ERROR", "hex": "#DC322F"}], "key": "#268BD2"}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := LoadThemeFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "solar" {
+		t.Errorf("got name %q, want solar", name)
+	}
+	cs, err := LookupTheme("solar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.Key != "#268BD2" || cs.Colors[0].Hex != "#DC322F" {
+		t.Errorf("got %+v", cs)
+	}
+
+	cfg := NewConfig()
+	cfg.Theme = "solar"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("config with a loaded theme: %v", err)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"colours": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadThemeFile(bad); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pokeyaro/gopkg/suprelog/internal"
+)
+
+// A VModuleRule overrides the log level for the source files matching Pattern.
+//
+// The pattern is matched with path.Match against as many trailing elements
+// of the file path as it has, so "cache.go" matches a file of that name in
+// any directory, "internal/db/*" the files of any internal/db directory and
+// "*_test.go" all test files.
+type VModuleRule struct {
+	Pattern string
+	Level   Level
+}
+
+// ParseVModule parses a comma-separated list of pattern=LEVEL overrides,
+// e.g. "internal/db/*=TRACE,cache.go=WARN".
+func ParseVModule(spec string) ([]VModuleRule, error) {
+	var rules []VModuleRule
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, name, ok := strings.Cut(item, "=")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("suprelog: invalid vmodule override %q", item)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("suprelog: invalid vmodule pattern %q: %w", pattern, err)
+		}
+		l, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, VModuleRule{Pattern: pattern, Level: l})
+	}
+	return rules, nil
+}
+
+// vmodule holds the level overrides of a Handler. It is replaced as a whole
+// when they change. The override of each call site is cached by its PC.
+type vmodule struct {
+	rules []VModuleRule
+	min   Level    // lowest level of the rules
+	cache sync.Map // uintptr -> vmoduleLevel
+}
+
+type vmoduleLevel struct {
+	level   Level
+	matched bool
+}
+
+func newVModule(rules []VModuleRule) *vmodule {
+	if len(rules) == 0 {
+		return nil
+	}
+	v := &vmodule{rules: rules, min: rules[0].Level}
+	for _, r := range rules[1:] {
+		v.min = min(v.min, r.Level)
+	}
+	return v
+}
+
+// level returns the level of the first rule matching the source file of
+// pc, and reports whether there was one.
+func (v *vmodule) level(pc uintptr) (Level, bool) {
+	if pc == 0 {
+		return 0, false
+	}
+	if cached, ok := v.cache.Load(pc); ok {
+		vl := cached.(vmoduleLevel)
+		return vl.level, vl.matched
+	}
+
+	file, _, _ := internal.GetSourceLocation(pc)
+	var vl vmoduleLevel
+	for _, r := range v.rules {
+		if matchFile(r.Pattern, file) {
+			vl = vmoduleLevel{level: r.Level, matched: true}
+			break
+		}
+	}
+	v.cache.Store(pc, vl)
+	return vl.level, vl.matched
+}
+
+// matchFile reports whether the trailing elements of file match pattern.
+func matchFile(pattern, file string) bool {
+	elems := strings.Split(filepath.ToSlash(file), "/")
+	n := strings.Count(pattern, "/") + 1
+	if n > len(elems) {
+		return false
+	}
+	ok, _ := path.Match(pattern, strings.Join(elems[len(elems)-n:], "/"))
+	return ok
+}
+
+// skipByVModule reports whether a record logged at level l from pc is below
+// the level of the matching override, or of the handler if none matches.
+func (h *Handler) skipByVModule(v *vmodule, l Level, pc uintptr) bool {
+	if ol, ok := v.level(pc); ok {
+		return l < ol
+	}
+	return l < h.level.Get()
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 Pokeya. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package suprelog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestParseVModule(t *testing.T) {
+	rules, err := ParseVModule("internal/db/*=TRACE, cache.go=warn,,x.go=-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []VModuleRule{{"internal/db/*", LevelTrace}, {"cache.go", LevelWarn}, {"x.go", Level(-2)}}
+	if len(rules) != len(want) {
+		t.Fatalf("got %v, want %v", rules, want)
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("rule %d: got %v, want %v", i, rules[i], want[i])
+		}
+	}
+
+	for _, spec := range []string{"cache.go", "=INFO", "cache.go=LOUD", "[=INFO"} {
+		if _, err := ParseVModule(spec); err == nil {
+			t.Errorf("ParseVModule(%q): want error", spec)
+		}
+	}
+}
+
+func TestMatchFile(t *testing.T) {
+	tests := []struct {
+		pattern, file string
+		want          bool
+	}{
+		{"cache.go", "/src/app/cache/cache.go", true},
+		{"cache.go", "/src/app/cache/store.go", false},
+		{"internal/db/*", "/src/app/internal/db/conn.go", true},
+		{"internal/db/*", "/src/app/internal/db/pool/conn.go", false},
+		{"*_test.go", "/src/app/x_test.go", true},
+		{"a/b/c/d.go", "c/d.go", false},
+	}
+	for _, tt := range tests {
+		if got := matchFile(tt.pattern, tt.file); got != tt.want {
+			t.Errorf("matchFile(%q, %q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestHandler_VModule(t *testing.T) {
+	tests := []struct {
+		rule VModuleRule
+		want string
+	}{
+		{VModuleRule{"suprelog/vmodule_test.go", LevelTrace}, "trace\ninfo\nerror\n"},
+		{VModuleRule{"*_test.go", LevelError}, "error\n"},
+		{VModuleRule{"other.go", LevelTrace}, "info\nerror\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		h := HandlerOptions(
+			WithWriter(&buf),
+			WithBuiltinSort([]string{}),
+			WithLogLevel(LevelInfo),
+			WithVModule(tt.rule),
+		)
+
+		log := NewEntry(h)
+		log.Trace("trace")
+		log.Info("info")
+		log.Error("error")
+
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.rule, got, tt.want)
+		}
+	}
+
+	h := HandlerOptions(WithLogLevel(LevelInfo))
+	if h.Enabled(context.Background(), LevelDebug.Level()) {
+		t.Error("DEBUG enabled without overrides")
+	}
+}
+
+func TestHandler_SetVModuleWhileLogging(t *testing.T) {
+	var buf bytes.Buffer
+	h := HandlerOptions(
+		WithWriter(&buf),
+		WithBuiltinSort([]string{}),
+		WithLogLevel(LevelInfo),
+	)
+	log := NewEntry(h.WithAttrs(nil))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			log.Trace("trace")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		h.SetVModule(VModuleRule{"*_test.go", LevelTrace})
+		h.SetVModule()
+	}
+	<-done
+
+	// The overrides of h apply to the handlers derived from it
+	buf.Reset()
+	h.SetVModule(VModuleRule{"*_test.go", LevelTrace})
+	log.Trace("derived")
+	if got, want := buf.String(), "derived\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}